@@ -0,0 +1,14 @@
+Feature: Automatic Cleanup
+
+  Scenario: Populated tables are cleaned after scenario
+    Given these rows are stored in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+
+    And these rows are stored in table "my_another_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+
+    And these rows are stored in table "my_table" of database "my_db"
+      | id | foo   |
+      | 2  | foo-2 |
@@ -0,0 +1,12 @@
+Feature: CSV Dialect
+
+  Scenario: Rows are loaded from files with custom CSV dialect
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    rows.csv
+    """
+
+    And rows from this file are stored in table "my_table" of database "my_db"
+    """
+    rows.tsv
+    """
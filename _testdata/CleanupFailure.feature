@@ -0,0 +1,6 @@
+Feature: Cleanup Failure
+
+  Scenario: Transactions are rolled back when cleanup fails
+    Given these rows are stored in table "my_table" of database "my_another_db"
+      | id | foo   |
+      | 1  | foo-1 |
@@ -0,0 +1,7 @@
+Feature: Row Deletion
+
+  Scenario: Rows are deleted by matching columns
+    Given these rows are deleted from table "my_table" of database "my_db" expecting 03 deleted
+      | id   | foo   | meta      |
+      | 1    | NULL  | {"a":1}   |
+      | $any | foo-2 | NULL      |
@@ -0,0 +1,7 @@
+Feature: Dependent Tables Cleanup
+
+  Scenario: Listed tables are cleaned in order of dependencies
+    Given there are no rows in tables "users, orders, order_items" of database "my_db"
+
+  Scenario: All tables of database are cleaned in order of dependencies
+    Given there are no rows in database "my_db"
@@ -0,0 +1,4 @@
+Feature: Dependent Tables Cleanup With Introspection
+
+  Scenario: Tables of schema are cleaned in order of foreign keys
+    Given there are no rows in tables "shop.users, shop.orders" of database "my_db"
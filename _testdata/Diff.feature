@@ -0,0 +1,6 @@
+Feature: Row Diff
+
+  Scenario: Missing row is compared with closest row
+    Then these rows are available in table "my_table" of database "my_db"
+      | id  | foo   | bar |
+      | $id | foo-1 | abc |
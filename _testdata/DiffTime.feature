@@ -0,0 +1,6 @@
+Feature: Row Diff With Time
+
+  Scenario: Closest row matches equal instants in different locations
+    Then these rows are available in table "my_table" of database "my_db"
+      | id  | at                   | bar |
+      | $id | 2021-01-01T00:00:00Z | abc |
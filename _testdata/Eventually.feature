@@ -0,0 +1,8 @@
+Feature: Eventual Assertions
+
+  Scenario: Rows appear after a while
+    Then eventually only these rows are available in table "my_table" of database "my_db" within 1s
+      | id  | foo   |
+      | $id | foo-1 |
+
+    And eventually no rows are available in table "my_another_table" of database "my_db"
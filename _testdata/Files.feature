@@ -0,0 +1,7 @@
+Feature: Rows From Files
+
+  Scenario: Rows are loaded from a file
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    rows.csv
+    """
@@ -0,0 +1,7 @@
+Feature: Rows From Gherkin Table File
+
+  Scenario: Rows are loaded from a gherkin table file
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    rows.table
+    """
@@ -0,0 +1,4 @@
+Feature: Named Fixtures
+
+  Scenario: Fixture with included fixture is loaded
+    Given fixture "shop" is loaded into database "my_db"
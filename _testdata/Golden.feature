@@ -0,0 +1,5 @@
+Feature: Golden Files
+
+  Scenario: Table contents match golden files
+    Then table "my_table" of database "my_db" matches golden file "my_table.csv"
+    And table "my_table" of database "my_db" matches golden file "my_table.table"
@@ -0,0 +1,4 @@
+Feature: Golden Files With Time
+
+  Scenario: Table contents with sub-second time match golden file
+    Then table "my_table" of database "my_db" matches golden file "my_table.csv"
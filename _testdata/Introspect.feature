@@ -0,0 +1,10 @@
+Feature: Schema Introspection
+
+  Scenario: Unregistered table is resolved from schema
+    Given these rows are stored in table "users" of database "my_db"
+      | id | name | created_at           |
+      | 1  | foo  | 2021-01-01T00:00:00Z |
+
+    Then these rows are available in table "users" of database "my_db"
+      | id  | name | created_at           |
+      | $id | foo  | 2021-01-01T00:00:00Z |
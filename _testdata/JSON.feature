@@ -0,0 +1,12 @@
+Feature: JSON Files
+
+  Scenario: Rows are stored and asserted with JSON files
+    Given rows from this file are stored in table "my_table" of database "my_db"
+    """
+    _testdata/rows.json
+    """
+
+    Then rows from this file are available in table "my_table" of database "my_db"
+    """
+    _testdata/rows.ndjson
+    """
@@ -0,0 +1,18 @@
+Feature: JSON Rows
+
+  Scenario: Rows are stored and asserted with JSON docstrings
+    Given these rows are stored in table "my_table" of database "my_db" as JSON:
+    """
+    [
+      {"id": 1, "name": "foo", "meta": {"tags": ["a", "b"]}},
+      {"id": 2, "name": null}
+    ]
+    """
+
+    Then only these rows are available in table "my_table" of database "my_db" as JSON:
+    """
+    [
+      {"id": "$id1", "name": "foo", "meta": {"tags": ["a", "b"]}},
+      {"id": 2, "name": null, "meta": null}
+    ]
+    """
@@ -0,0 +1,17 @@
+Feature: Empty JSON Rows
+
+  Scenario: Empty JSON array means no rows
+    Given these rows are stored in table "my_table" of database "my_db" as JSON:
+    """
+    []
+    """
+
+    Then these rows are available in table "my_table" of database "my_db" as JSON:
+    """
+    []
+    """
+
+    And only these rows are available in table "my_table" of database "my_db" as JSON:
+    """
+    []
+    """
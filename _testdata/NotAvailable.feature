@@ -0,0 +1,7 @@
+Feature: Negative Assertions
+
+  Scenario: Rows are not available
+    Then these rows are not available in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+      | 2  | foo-2 |
@@ -0,0 +1,12 @@
+Feature: Ordered Assertions
+
+  Scenario: Rows are available in order
+    Then only these rows are available in table "my_table" of database "my_db" in this order
+      | id  | foo   |
+      | $id | foo-2 |
+      | 1   | foo-1 |
+
+    Then these rows are available in table "my_table" of database "my_db" in this order by "id"
+      | id  | foo   |
+      | 1   | foo-1 |
+      | $id | foo-1 |
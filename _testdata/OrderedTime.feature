@@ -0,0 +1,6 @@
+Feature: Ordered Assertions With Time
+
+  Scenario: Equal instants in different locations match
+    Then only these rows are available in table "my_table" of database "my_db" in this order
+      | id | at                   |
+      | 1  | 2021-01-01T00:00:00Z |
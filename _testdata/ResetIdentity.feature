@@ -0,0 +1,5 @@
+Feature: Reset Identity
+
+  Scenario: Auto increment sequences are restarted after rows are deleted
+    Given there are no rows in table "users" of database "pg"
+    And there are no rows in table "users" of database "my"
@@ -0,0 +1,4 @@
+Feature: Reset Identity In Transaction
+
+  Scenario: Reset identity is rejected for transactional instance
+    Given there are no rows in table "users" of database "my"
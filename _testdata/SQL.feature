@@ -0,0 +1,11 @@
+Feature: Raw SQL
+
+  Scenario: Statement is executed with bound variables
+    Given these rows are available in table "my_table" of database "my_db"
+      | id  | foo   |
+      | $id | foo-1 |
+
+    When I execute SQL in database "my_db" expecting 1 affected:
+    """
+    UPDATE my_table SET foo = 'bar?' WHERE id = $id
+    """
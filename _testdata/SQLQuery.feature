@@ -0,0 +1,12 @@
+Feature: SQL Query Result
+
+  Scenario: Query result matches table
+    Given SQL query in database "my_db" is:
+    """
+    SELECT foo, COUNT(1) AS cnt, MAX(created_at) AS last, MAX(meta) AS meta FROM my_table GROUP BY foo ORDER BY foo
+    """
+
+    Then the result of SQL query in database "my_db" matches:
+      | foo   | cnt  | last       | meta             |
+      | foo-1 | $cnt | 2021-01-02 | {"b":2, "a":1}   |
+      | foo-2 | 1    | NULL       | NULL             |
@@ -0,0 +1,17 @@
+Feature: Raw SQL With Quoted Sections
+
+  Scenario: Variables are not replaced in quoted sections
+    Given I store SQL query result in database "my_db" into variables $id:
+    """
+    SELECT 12
+    """
+
+    When I execute SQL in database "my_db":
+    """
+    CREATE FUNCTION touch() RETURNS trigger AS $body$ BEGIN NEW.foo = '$id?'; RETURN NEW; END; $body$ LANGUAGE plpgsql
+    """
+
+    Then I execute SQL in database "my_db" expecting 1 affected:
+    """
+    UPDATE "my$table" SET foo = '$id', bar = 'it''s $id' WHERE id = $id
+    """
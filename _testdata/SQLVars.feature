@@ -0,0 +1,21 @@
+Feature: SQL Query Variables
+
+  Scenario: Query result is stored in variables
+    When I store SQL query result in database "my_db" into variables $total, $lastID:
+    """
+    SELECT COUNT(1), MAX(id) FROM my_table
+    """
+
+    And SQL query in database "my_db" is:
+    """
+    SELECT name FROM my_table WHERE id = $lastID
+    """
+
+    And I store the result of SQL query in database "my_db" into variables:
+      | name  |
+      | $name |
+
+    Then I execute SQL in database "my_db" expecting 03 affected:
+    """
+    UPDATE my_table SET name = $name WHERE id < $total
+    """
@@ -0,0 +1,7 @@
+Feature: Database Snapshot
+
+  Scenario: Snapshot is taken after expensive setup
+    Given database "my_db" snapshot "baseline" is taken
+
+  Scenario: Database is restored to snapshot
+    Given database "my_db" is restored to snapshot "baseline"
@@ -0,0 +1,12 @@
+Feature: Transactional Scenario
+
+  Scenario: Changes are rolled back
+    Given there are no rows in table "my_table" of database "my_db"
+
+    And these rows are stored in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
+
+    Then only these rows are available in table "my_table" of database "my_db"
+      | id | foo   |
+      | 1  | foo-1 |
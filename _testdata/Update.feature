@@ -0,0 +1,10 @@
+Feature: Row Update
+
+  Scenario: Rows are updated by key
+    Given these rows are updated in table "my_table" of database "my_db" by key "id"
+      | id | foo   |
+      | 1  | foo-2 |
+
+    And these rows are updated in table "my_table" of database "my_db" by key "id"
+      | id | foo   |
+      | 2  | foo-3 |
@@ -0,0 +1,7 @@
+Feature: YAML Data
+
+  Scenario: Multiple tables are populated from a YAML file
+    Given data from this file is stored in database "my_db"
+    """
+    _testdata/data.yaml
+    """
@@ -0,0 +1,193 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type touchedTablesCtxKey struct{}
+
+// touchedTable identifies a table that was populated during scenario.
+type touchedTable struct {
+	dbName    string
+	tableName string
+}
+
+// touchedTables keeps tables populated during scenario in order of first insert.
+type touchedTables struct {
+	tables []touchedTable
+}
+
+func (tt *touchedTables) add(dbName, tableName string) {
+	for _, t := range tt.tables {
+		if t.dbName == dbName && t.tableName == tableName {
+			return
+		}
+	}
+
+	tt.tables = append(tt.tables, touchedTable{dbName: dbName, tableName: tableName})
+}
+
+// trackTable registers table as populated in scenario context.
+func trackTable(ctx context.Context, dbName, tableName string) {
+	if tt, ok := ctx.Value(touchedTablesCtxKey{}).(*touchedTables); ok {
+		tt.add(dbName, tableName)
+	}
+}
+
+// cleanupTables deletes rows from tables populated during scenario in instances with AutoCleanup.
+//
+// Tables are cleaned in reverse order of first insert adjusted with table dependencies,
+// so that dependent rows are deleted before parents.
+func (m *Manager) cleanupTables(ctx context.Context) error {
+	tt, ok := ctx.Value(touchedTablesCtxKey{}).(*touchedTables)
+	if !ok {
+		return nil
+	}
+
+	var (
+		dbNames []string
+		tables  = make(map[string][]string)
+	)
+
+	for i := len(tt.tables) - 1; i >= 0; i-- {
+		t := tt.tables[i]
+		instance := m.Instances[t.dbName]
+
+		// Transactional instance is cleaned with rollback.
+		if !instance.AutoCleanup || instance.Transactional {
+			continue
+		}
+
+		if _, ok := tables[t.dbName]; !ok {
+			dbNames = append(dbNames, t.dbName)
+		}
+
+		tables[t.dbName] = append(tables[t.dbName], t.tableName)
+	}
+
+	for _, dbName := range dbNames {
+		if err := m.noRowsInTablesOfDatabase(ctx, tables[dbName], dbName); err != nil {
+			return fmt.Errorf("failed to clean up: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// noRowsInTablesOfDatabase deletes rows from multiple tables with children tables cleaned before parents.
+func (m *Manager) noRowsInTablesOfDatabase(ctx context.Context, tableNames []string, dbName string) error {
+	order, err := m.deletionOrder(ctx, tableNames, dbName)
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range order {
+		if err := m.noRowsInTableOfDatabase(ctx, tableName, dbName); err != nil {
+			return err
+		}
+
+		if m.Logger != nil {
+			m.Logger.Info(ctx, "cleaned up table", "table", tableName, "database", dbName)
+		}
+	}
+
+	return nil
+}
+
+func (m *Manager) noRowsInAllTablesOfDatabase(ctx context.Context, dbName string) error {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	tableNames := make([]string, 0, len(instance.Tables))
+
+	for tableName := range instance.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+
+	sort.Strings(tableNames)
+
+	return m.noRowsInTablesOfDatabase(ctx, tableNames, dbName)
+}
+
+// splitTables parses comma-separated list of table names.
+func splitTables(tables string) []string {
+	names := strings.Split(tables, ",")
+
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+
+	return names
+}
+
+// deletionOrder sorts tables so that every table goes before tables that it references.
+//
+// References are taken from Instance.Dependencies or from database schema if Instance.Introspect is enabled,
+// otherwise order is preserved.
+func (m *Manager) deletionOrder(ctx context.Context, tableNames []string, dbName string) ([]string, error) {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	requested := make(map[string]bool, len(tableNames))
+
+	for _, tableName := range tableNames {
+		requested[tableName] = true
+	}
+
+	parents := make(map[string][]string, len(tableNames))
+
+	for _, tableName := range tableNames {
+		deps, ok := instance.Dependencies[tableName]
+		if !ok && instance.Introspect {
+			refs, err := referencedTables(instanceCtx(ctx, dbName), instance.Storage, tableName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to introspect references of table %s in database %s: %w",
+					tableName, dbName, err)
+			}
+
+			deps = refs
+		}
+
+		parents[tableName] = deps
+	}
+
+	// Depth-first traversal puts parents before children, reversed result puts children first.
+	var (
+		visited = make(map[string]bool, len(tableNames))
+		order   = make([]string, 0, len(tableNames))
+		visit   func(tableName string)
+	)
+
+	visit = func(tableName string) {
+		if visited[tableName] {
+			return
+		}
+
+		visited[tableName] = true
+
+		for _, p := range parents[tableName] {
+			if requested[p] && p != tableName {
+				visit(p)
+			}
+		}
+
+		order = append(order, tableName)
+	}
+
+	for i := len(tableNames) - 1; i >= 0; i-- {
+		visit(tableNames[i])
+	}
+
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+
+	return order, nil
+}
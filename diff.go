@@ -0,0 +1,140 @@
+package dbdog
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/bool64/sqluct"
+)
+
+// closestRowsLimit limits number of rows that are scanned to find the closest row.
+const closestRowsLimit = 1000
+
+// closestRow finds table row that has most columns equal to expected row and renders a diff with it.
+func (t *tableQuery) closestRow(row interface{}, rawValues []string) (string, error) {
+	qb := t.storage.QueryBuilder().
+		Select(t.colNames...).
+		From(t.table).
+		Limit(closestRowsLimit)
+
+	received := reflect.New(reflect.SliceOf(reflect.TypeOf(row).Elem()))
+
+	if err := t.storage.Select(t.ctx, qb, received.Interface()); err != nil {
+		return "", err
+	}
+
+	if received.Elem().Len() == 0 {
+		return "", nil
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+	argsExp := combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption))
+
+	var (
+		bestScore    = -1
+		bestRcv      map[string]interface{}
+		bestMismatch []bool
+	)
+
+	for i := 0; i < received.Elem().Len(); i++ {
+		argsRcv := combine(t.storage.Mapper.ColumnsValues(received.Elem().Index(i).Addr(), colOption))
+		mismatch := make([]bool, len(t.colNames))
+		score := 0
+
+		for j, col := range t.colNames {
+			// Variables without values match any value.
+			if t.vars.IsVar(rawValues[j]) {
+				if _, found := t.vars.Get(rawValues[j]); !found {
+					continue
+				}
+			}
+
+			if valuesEqual(indirect(argsExp[col]), indirect(argsRcv[col])) {
+				score++
+			} else {
+				mismatch[j] = true
+			}
+		}
+
+		if score > bestScore {
+			bestScore = score
+			bestRcv = argsRcv
+			bestMismatch = mismatch
+		}
+	}
+
+	actual := make([]string, 0, len(t.colNames))
+
+	for _, col := range t.colNames {
+		actual = append(actual, t.formatValue(bestRcv[col]))
+	}
+
+	return renderDiff(t.colNames, rawValues, actual, bestMismatch), nil
+}
+
+// formatValue converts Go value of row field to a string.
+func (t *tableQuery) formatValue(v interface{}) string {
+	v = indirect(v)
+
+	if vr, ok := v.(driver.Valuer); ok {
+		if dv, err := vr.Value(); err == nil {
+			v = dv
+		}
+	}
+
+	if v == nil {
+		return null
+	}
+
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+
+	if t.mapper.Encoder != nil {
+		if vv, err := t.mapper.Encoder.Encode(v); err == nil && len(vv[""]) == 1 {
+			return vv[""][0]
+		}
+	}
+
+	if j, err := json.Marshal(v); err == nil {
+		return string(j)
+	}
+
+	return fmt.Sprintf("%v", v)
+}
+
+// renderDiff renders expected and actual rows as a table with mismatched cells marked with ^.
+func renderDiff(colNames, expected, actual []string, mismatch []bool) string {
+	width := make([]int, len(colNames))
+
+	for i, col := range colNames {
+		for _, v := range []string{col, expected[i], actual[i]} {
+			if len(v) > width[i] {
+				width[i] = len(v)
+			}
+		}
+	}
+
+	line := func(prefix string, cells []string) string {
+		res := "| " + prefix + " |"
+
+		for i, v := range cells {
+			res += " " + v + strings.Repeat(" ", width[i]-len(v)) + " |"
+		}
+
+		return res + "\n"
+	}
+
+	markers := make([]string, len(colNames))
+
+	for i := range colNames {
+		if mismatch[i] {
+			markers[i] = strings.Repeat("^", width[i])
+		}
+	}
+
+	return line(" ", colNames) + line("-", expected) + line("+", actual) + line(" ", markers)
+}
@@ -0,0 +1,177 @@
+package dbdog
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bool64/shared"
+	"github.com/cucumber/godog"
+)
+
+// RetryPolicy controls polling of eventually consistent assertions.
+//
+// Zero fields are replaced with defaults.
+type RetryPolicy struct {
+	// Timeout is a maximum duration of polling, default 5s.
+	Timeout time.Duration
+	// Interval is a delay before the second attempt, default 100ms.
+	// Delay is doubled after every failed attempt.
+	Interval time.Duration
+	// MaxInterval limits delay between attempts, default 1s.
+	MaxInterval time.Duration
+}
+
+// merge fills zero fields with values from fallback policy.
+func (p RetryPolicy) merge(fallback RetryPolicy) RetryPolicy {
+	if p.Timeout == 0 {
+		p.Timeout = fallback.Timeout
+	}
+
+	if p.Interval == 0 {
+		p.Interval = fallback.Interval
+	}
+
+	if p.MaxInterval == 0 {
+		p.MaxInterval = fallback.MaxInterval
+	}
+
+	return p
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	Timeout:     5 * time.Second,
+	Interval:    100 * time.Millisecond,
+	MaxInterval: time.Second,
+}
+
+func (m *Manager) registerEventualAssertions(s *godog.ScenarioContext) {
+	s.Step(`eventually (only )?rows from this file are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, only, tableName, database, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, only != "", tableName, database, within, filePath.Content)
+		})
+
+	s.Step(`eventually (only )?these rows are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, only, tableName, database, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, database, within, Rows(data), only != "")
+		})
+
+	s.Step(`eventually no rows are available in table "([^"]*)" of database "([^"]*)"(?: within ([^\s:]+))?$`,
+		func(ctx context.Context, tableName, database, within string) error {
+			return m.eventuallyAssertRows(ctx, tableName, database, within, nil, true)
+		})
+
+	s.Step(`eventually (only )?rows from this file are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, only, tableName, within string, filePath *godog.DocString) error {
+			return m.eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, only != "", tableName, DefaultDatabase, within, filePath.Content)
+		})
+
+	s.Step(`eventually (only )?these rows are available in table "([^"]*)"(?: within ([^\s:]+))?[:]?$`,
+		func(ctx context.Context, only, tableName, within string, data *godog.Table) error {
+			return m.eventuallyAssertRows(ctx, tableName, DefaultDatabase, within, Rows(data), only != "")
+		})
+
+	s.Step(`eventually no rows are available in table "([^"]*)"(?: within ([^\s:]+))?$`,
+		func(ctx context.Context, tableName, within string) error {
+			return m.eventuallyAssertRows(ctx, tableName, DefaultDatabase, within, nil, true)
+		})
+}
+
+func (m *Manager) eventuallyRowsFromThisFileAreAvailableInTableOfDatabase(
+	ctx context.Context, exhaustiveList bool, tableName, dbName, within, filePath string,
+) error {
+	data, err := m.loadTableFromFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load rows from file: %w", err)
+	}
+
+	return m.eventuallyAssertRows(ctx, tableName, dbName, within, data, exhaustiveList)
+}
+
+// retryPolicy resolves retry policy of database instance, non-empty within overrides timeout.
+func (m *Manager) retryPolicy(dbName, within string) (RetryPolicy, error) {
+	p := m.Instances[dbName].Retry.merge(m.Retry).merge(defaultRetryPolicy)
+
+	if within != "" {
+		timeout, err := time.ParseDuration(within)
+		if err != nil {
+			return p, fmt.Errorf("failed to parse timeout %q: %w", within, err)
+		}
+
+		p.Timeout = timeout
+	}
+
+	return p, nil
+}
+
+// eventuallyAssertRows repeats rows assertion until it succeeds or timeout is reached.
+//
+// Every attempt receives a copy of variables, so that values collected by a failed attempt are discarded.
+func (m *Manager) eventuallyAssertRows(
+	ctx context.Context, tableName, dbName, within string, data [][]string, exhaustiveList bool,
+) (err error) {
+	p, err := m.retryPolicy(dbName, within)
+	if err != nil {
+		return err
+	}
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose table contents of last attempt to simplify test debugging.
+		if err != nil {
+			err = t.exposeContents(err)
+		}
+	}()
+
+	var (
+		deadline = time.Now().Add(p.Timeout)
+		interval = p.Interval
+		attempt  = 0
+	)
+
+	for {
+		attempt++
+
+		t.vars = copyVars(m.Vars)
+
+		err = t.assertRows(exhaustiveList)
+		if err == nil {
+			for k, v := range t.vars.GetAll() {
+				if _, found := m.Vars.Get(k); !found {
+					m.Vars.Set(k, v)
+				}
+			}
+
+			return nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("assertion failed after %d attempts in %s: %w", attempt, p.Timeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("assertion interrupted after %d attempts: %w", attempt, err)
+		case <-time.After(interval):
+		}
+
+		interval *= 2
+		if interval > p.MaxInterval {
+			interval = p.MaxInterval
+		}
+	}
+}
+
+func copyVars(vars *shared.Vars) *shared.Vars {
+	c := &shared.Vars{VarPrefix: vars.VarPrefix}
+
+	for k, v := range vars.GetAll() {
+		c.Set(k, v)
+	}
+
+	return c
+}
@@ -0,0 +1,421 @@
+package dbdog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	errGherkinRowExpected   = errors.New("gherkin table row expected")
+	errGherkinRowCells      = errors.New("unexpected number of cells in gherkin table row")
+	errJSONArrayExpected    = errors.New("JSON array expected")
+	errJSONObjectExpected   = errors.New("JSON object expected")
+	errYAMLMappingExpected  = errors.New("YAML mapping expected")
+	errYAMLSequenceExpected = errors.New("YAML sequence of rows expected")
+)
+
+// CSVDialect configures reading of CSV files.
+type CSVDialect struct {
+	// Comma is a field delimiter, default ','. Files with .tsv extension are always tab-separated.
+	Comma rune
+
+	// Comment, if not 0, is a comment character, lines beginning with it are ignored.
+	Comment rune
+
+	// LazyQuotes allows quotes in unquoted fields and non-doubled quotes in quoted fields.
+	LazyQuotes bool
+
+	// TrimLeadingSpace ignores leading white space in fields.
+	TrimLeadingSpace bool
+
+	// Null is a token of NULL value in file, default "NULL".
+	Null string
+}
+
+// readTable reads rows according to file extension: .json (array of objects), .ndjson or .jsonl (object per line),
+// .table (gherkin table), CSV is used for other extensions.
+func readTable(r io.Reader, filePath string, dialect CSVDialect) ([][]string, error) {
+	switch strings.ToLower(path.Ext(filePath)) {
+	case ".json":
+		return readJSON(r)
+	case ".ndjson", ".jsonl":
+		return readNDJSON(r)
+	case ".tsv":
+		dialect.Comma = '\t'
+
+		return readCSV(r, dialect)
+	case ".table":
+		return readGherkinTable(r)
+	default:
+		return readCSV(r, dialect)
+	}
+}
+
+// readCSV reads rows of CSV file, NULL tokens of dialect are replaced with NULL.
+func readCSV(r io.Reader, dialect CSVDialect) ([][]string, error) {
+	c := csv.NewReader(r)
+	c.Comment = dialect.Comment
+	c.LazyQuotes = dialect.LazyQuotes
+	c.TrimLeadingSpace = dialect.TrimLeadingSpace
+
+	if dialect.Comma != 0 {
+		c.Comma = dialect.Comma
+	}
+
+	rows, err := c.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	if dialect.Null != "" && dialect.Null != null {
+		// Header is skipped.
+		for j := 1; j < len(rows); j++ {
+			for i, cell := range rows[j] {
+				if cell == dialect.Null {
+					rows[j][i] = null
+				}
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// readGherkinTable reads rows of gherkin table, empty lines and lines starting with # are ignored.
+func readGherkinTable(r io.Reader) ([][]string, error) {
+	var rows [][]string
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.HasPrefix(line, "|") || !strings.HasSuffix(line, "|") || len(line) < 2 {
+			return nil, fmt.Errorf("%w at line %d: %q", errGherkinRowExpected, lineNum, line)
+		}
+
+		cells := gherkinCells(line[1 : len(line)-1])
+
+		if len(rows) > 0 && len(cells) != len(rows[0]) {
+			return nil, fmt.Errorf("%w at line %d: %d received, %d expected",
+				errGherkinRowCells, lineNum, len(cells), len(rows[0]))
+		}
+
+		rows = append(rows, cells)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read table: %w", err)
+	}
+
+	return rows, nil
+}
+
+// gherkinCells splits gherkin row by unescaped pipes, \| and \\ escapes are unescaped.
+func gherkinCells(line string) []string {
+	var (
+		cells []string
+		cell  strings.Builder
+	)
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && (line[i+1] == '|' || line[i+1] == '\\'):
+			i++
+			cell.WriteByte(line[i])
+		case line[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(line[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// readJSON reads an array of JSON objects as table rows.
+func readJSON(r io.Reader) ([][]string, error) {
+	dec := json.NewDecoder(r)
+	jr := objectRows{}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return nil, fmt.Errorf("failed to read JSON: %w", errJSONArrayExpected)
+	}
+
+	for dec.More() {
+		if err := jr.add(dec); err != nil {
+			return nil, fmt.Errorf("failed to read JSON row %d: %w", len(jr.rows), err)
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON: %w", err)
+	}
+
+	return jr.table(), nil
+}
+
+// readNDJSON reads newline-delimited JSON objects as table rows.
+func readNDJSON(r io.Reader) ([][]string, error) {
+	dec := json.NewDecoder(r)
+	jr := objectRows{}
+
+	for {
+		err := jr.add(dec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read NDJSON row %d: %w", len(jr.rows), err)
+		}
+	}
+
+	return jr.table(), nil
+}
+
+// objectRows collects JSON or YAML objects as rows with columns in order of first appearance.
+type objectRows struct {
+	columns []string
+	rows    []map[string]string
+}
+
+// add reads a JSON object from decoder.
+func (jr *objectRows) add(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return fmt.Errorf("%w, %v received", errJSONObjectExpected, tok)
+	}
+
+	row := make(map[string]string)
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, _ := tok.(string)
+
+		var raw json.RawMessage
+
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+
+		cell, err := jsonCell(raw)
+		if err != nil {
+			return fmt.Errorf("failed to read value of %s: %w", key, err)
+		}
+
+		jr.addColumn(key)
+		row[key] = cell
+	}
+
+	// Closing brace.
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	jr.rows = append(jr.rows, row)
+
+	return nil
+}
+
+func (jr *objectRows) addColumn(name string) {
+	for _, c := range jr.columns {
+		if c == name {
+			return
+		}
+	}
+
+	jr.columns = append(jr.columns, name)
+}
+
+// table returns rows with header, missing values are NULL, so that they are asserted with IS NULL.
+func (jr *objectRows) table() [][]string {
+	data := make([][]string, 0, len(jr.rows)+1)
+	data = append(data, jr.columns)
+
+	for _, row := range jr.rows {
+		r := make([]string, 0, len(jr.columns))
+
+		for _, c := range jr.columns {
+			if v, ok := row[c]; ok {
+				r = append(r, v)
+			} else {
+				r = append(r, null)
+			}
+		}
+
+		data = append(data, r)
+	}
+
+	return data
+}
+
+// jsonCell converts JSON value to table cell, objects and arrays are kept as compact JSON.
+func jsonCell(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+
+	switch raw[0] {
+	case 'n':
+		return null, nil
+	case '"':
+		var s string
+
+		err := json.Unmarshal(raw, &s)
+
+		return s, err
+	case '{', '[':
+		buf := bytes.NewBuffer(nil)
+
+		err := json.Compact(buf, raw)
+
+		return buf.String(), err
+	default:
+		return string(raw), nil
+	}
+}
+
+// readYAMLTables reads rows of multiple tables from a YAML mapping of table names to lists of rows,
+// a mapping of database name to such mapping can be used instead of a list to define tables of another database.
+// Tables with empty lists are skipped.
+//
+//	users:
+//	  - {id: 1, name: foo}
+//	my_another_db:
+//	  orders:
+//	    - {id: 1, user_id: 1}
+func readYAMLTables(r io.Reader) ([]fixtureTable, error) {
+	var doc yaml.Node
+
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read YAML: %w", err)
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w at line %d", errYAMLMappingExpected, root.Line)
+	}
+
+	var tables []fixtureTable
+
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		name, value := root.Content[i].Value, root.Content[i+1]
+
+		if value.Kind != yaml.MappingNode {
+			data, err := yamlRows(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rows of %s: %w", name, err)
+			}
+
+			if len(data) > 1 {
+				tables = append(tables, fixtureTable{tableName: name, data: data})
+			}
+
+			continue
+		}
+
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			tableName := value.Content[j].Value
+
+			data, err := yamlRows(value.Content[j+1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read rows of %s in %s: %w", tableName, name, err)
+			}
+
+			if len(data) > 1 {
+				tables = append(tables, fixtureTable{dbName: name, tableName: tableName, data: data})
+			}
+		}
+	}
+
+	return tables, nil
+}
+
+// yamlRows converts a YAML sequence of mappings into table rows.
+func yamlRows(node *yaml.Node) ([][]string, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%w at line %d", errYAMLSequenceExpected, node.Line)
+	}
+
+	or := objectRows{}
+
+	for _, item := range node.Content {
+		if item.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%w at line %d", errYAMLMappingExpected, item.Line)
+		}
+
+		row := make(map[string]string)
+
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key := item.Content[i].Value
+
+			cell, err := yamlCell(item.Content[i+1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to read value of %s: %w", key, err)
+			}
+
+			or.addColumn(key)
+			row[key] = cell
+		}
+
+		or.rows = append(or.rows, row)
+	}
+
+	return or.table(), nil
+}
+
+// yamlCell converts YAML value to table cell, mappings and sequences are converted to compact JSON.
+func yamlCell(node *yaml.Node) (string, error) {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!null" {
+			return null, nil
+		}
+
+		return node.Value, nil
+	}
+
+	var v interface{}
+
+	if err := node.Decode(&v); err != nil {
+		return "", err
+	}
+
+	j, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(j), nil
+}
@@ -0,0 +1,248 @@
+package dbdog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/cucumber/godog"
+)
+
+const fixtureIncludeFile = "include.txt"
+
+// fixtureTableExts lists extensions of table files in fixture directory.
+var fixtureTableExts = map[string]bool{
+	".csv":    true,
+	".tsv":    true,
+	".table":  true,
+	".json":   true,
+	".ndjson": true,
+	".jsonl":  true,
+}
+
+var errMissingFixtures = errors.New("missing fixtures file system, Manager.Fixtures is not configured")
+
+// fixtureTable is table data of a fixture.
+type fixtureTable struct {
+	dbName    string
+	tableName string
+	data      [][]string
+}
+
+func (m *Manager) registerFixtures(s *godog.ScenarioContext) {
+	s.Step(`fixture "([^"]*)" is loaded into database "([^"]*)"$`,
+		m.fixtureIsLoadedIntoDatabase)
+
+	s.Step(`fixture "([^"]*)" is loaded$`,
+		func(ctx context.Context, name string) error {
+			return m.fixtureIsLoadedIntoDatabase(ctx, name, DefaultDatabase)
+		})
+
+	s.Step(`data from this file is stored in database "([^"]*)"[:]?$`,
+		func(ctx context.Context, database string, filePath *godog.DocString) error {
+			return m.dataFromThisFileIsStoredInDatabase(ctx, database, filePath.Content)
+		})
+
+	s.Step(`data from this file is stored[:]?$`,
+		func(ctx context.Context, filePath *godog.DocString) error {
+			return m.dataFromThisFileIsStoredInDatabase(ctx, DefaultDatabase, filePath.Content)
+		})
+}
+
+// dataFromThisFileIsStoredInDatabase populates tables from a YAML file, see readYAMLTables.
+func (m *Manager) dataFromThisFileIsStoredInDatabase(ctx context.Context, dbName, filePath string) (err error) {
+	f, err := m.openFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load data from file: %w", err)
+	}
+
+	defer func() {
+		clErr := f.Close()
+		if clErr != nil && err == nil {
+			err = clErr
+		}
+	}()
+
+	tables, err := readYAMLTables(f)
+	if err != nil {
+		return fmt.Errorf("failed to load data from file: %w", err)
+	}
+
+	var dbNames []string
+
+	byDB := make(map[string][]fixtureTable)
+
+	for _, t := range tables {
+		if t.dbName == "" {
+			t.dbName = dbName
+		}
+
+		if _, ok := byDB[t.dbName]; !ok {
+			dbNames = append(dbNames, t.dbName)
+		}
+
+		byDB[t.dbName] = append(byDB[t.dbName], t)
+	}
+
+	for _, name := range dbNames {
+		if err := m.storeTables(ctx, name, byDB[name]); err != nil {
+			return fmt.Errorf("failed to store data from file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// fixtureIsLoadedIntoDatabase stores rows of a fixture and its includes, parent tables are populated first.
+func (m *Manager) fixtureIsLoadedIntoDatabase(ctx context.Context, name, dbName string) error {
+	if m.Fixtures == nil {
+		return errMissingFixtures
+	}
+
+	var tables []fixtureTable
+
+	if err := m.collectFixture(name, make(map[string]bool), &tables); err != nil {
+		return fmt.Errorf("failed to load fixture %s: %w", name, err)
+	}
+
+	if err := m.storeTables(ctx, dbName, tables); err != nil {
+		return fmt.Errorf("failed to load fixture %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// storeTables populates tables of a database, parent tables are populated first,
+// order of appearance is kept for independent tables.
+func (m *Manager) storeTables(ctx context.Context, dbName string, tables []fixtureTable) error {
+	var tableNames []string
+
+	byTable := make(map[string][]fixtureTable)
+
+	for _, t := range tables {
+		if _, ok := byTable[t.tableName]; !ok {
+			tableNames = append(tableNames, t.tableName)
+		}
+
+		byTable[t.tableName] = append(byTable[t.tableName], t)
+	}
+
+	// Deletion order of reversed names is reversed again to keep order of appearance of independent tables.
+	for i, j := 0, len(tableNames)-1; i < j; i, j = i+1, j-1 {
+		tableNames[i], tableNames[j] = tableNames[j], tableNames[i]
+	}
+
+	order, err := m.deletionOrder(ctx, tableNames, dbName)
+	if err != nil {
+		return err
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		for _, t := range byTable[order[i]] {
+			if err := m.theseRowsAreStoredInTableOfDatabase(ctx, t.tableName, dbName, t.data); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// collectFixture reads tables of a fixture directory, included fixtures are collected first and only once.
+func (m *Manager) collectFixture(name string, visited map[string]bool, tables *[]fixtureTable) error {
+	if visited[name] {
+		return nil
+	}
+
+	visited[name] = true
+
+	includes, err := m.fixtureIncludes(name)
+	if err != nil {
+		return err
+	}
+
+	for _, include := range includes {
+		if err := m.collectFixture(include, visited, tables); err != nil {
+			return fmt.Errorf("failed to include fixture %s: %w", include, err)
+		}
+	}
+
+	entries, err := fs.ReadDir(m.Fixtures, name)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+
+		if e.IsDir() || !fixtureTableExts[ext] {
+			continue
+		}
+
+		data, err := readFixtureFile(m.Fixtures, path.Join(name, e.Name()), m.CSV)
+		if err != nil {
+			return err
+		}
+
+		*tables = append(*tables, fixtureTable{
+			tableName: strings.TrimSuffix(e.Name(), ext),
+			data:      data,
+		})
+	}
+
+	return nil
+}
+
+// fixtureIncludes reads names of included fixtures, one per line, lines starting with # are ignored.
+func (m *Manager) fixtureIncludes(name string) ([]string, error) {
+	f, err := m.Fixtures.Open(path.Join(name, fixtureIncludeFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var includes []string
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		includes = append(includes, line)
+	}
+
+	return includes, scanner.Err()
+}
+
+func readFixtureFile(fsys fs.FS, filePath string, dialect CSVDialect) ([][]string, error) {
+	f, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	data, err := readTable(f, filePath, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+
+	return data, nil
+}
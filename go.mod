@@ -1,10 +1,11 @@
 module github.com/bool64/dbdog
 
-go 1.13
+go 1.16
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.5.0
 	github.com/Masterminds/squirrel v1.5.2
+	github.com/bool64/ctxd v1.0.0
 	github.com/bool64/dev v0.1.43
 	github.com/bool64/shared v0.1.3
 	github.com/bool64/sqluct v0.1.9
@@ -15,4 +16,5 @@ require (
 	github.com/jmoiron/sqlx v1.3.4
 	github.com/stretchr/testify v1.7.0
 	github.com/swaggest/form/v5 v5.0.1
+	gopkg.in/yaml.v3 v3.0.1
 )
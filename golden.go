@@ -0,0 +1,215 @@
+package dbdog
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+)
+
+// UpdateGoldenEnv is the name of environment variable that enables updating of golden files.
+const UpdateGoldenEnv = "DBDOG_UPDATE_GOLDEN"
+
+var (
+	errUnsupportedGoldenFormat = errors.New("unsupported golden file format, .csv, .tsv or .table expected")
+	errReadOnlyGoldenFiles     = errors.New("golden files can not be updated in Manager.Files file system")
+)
+
+func (m *Manager) registerGolden(s *godog.ScenarioContext) {
+	s.Step(`table "([^"]*)" of database "([^"]*)" matches golden file "([^"]*)"$`,
+		m.tableOfDatabaseMatchesGoldenFile)
+
+	s.Step(`table "([^"]*)" matches golden file "([^"]*)"$`,
+		func(ctx context.Context, tableName, filePath string) error {
+			return m.tableOfDatabaseMatchesGoldenFile(ctx, tableName, DefaultDatabase, filePath)
+		})
+}
+
+// updateGolden checks if golden files should be updated instead of asserted.
+func (m *Manager) updateGolden() bool {
+	if m.UpdateGolden {
+		return true
+	}
+
+	// Invalid value disables update.
+	update, _ := strconv.ParseBool(os.Getenv(UpdateGoldenEnv))
+
+	return update
+}
+
+// tableOfDatabaseMatchesGoldenFile asserts table contents exhaustively with rows of golden file
+// or dumps table contents into golden file if update is enabled.
+func (m *Manager) tableOfDatabaseMatchesGoldenFile(ctx context.Context, tableName, dbName, filePath string) error {
+	if !m.updateGolden() {
+		data, err := m.loadTableFromFile(ctx, filePath)
+		if err != nil {
+			return fmt.Errorf("failed to load rows from golden file: %w", err)
+		}
+
+		// Golden file of an empty table has only header.
+		if len(data) < 2 {
+			return m.noRowsAreAvailableInTableOfDatabase(ctx, tableName, dbName)
+		}
+
+		return m.onlyTheseRowsAreAvailableInTableOfDatabase(ctx, tableName, dbName, data)
+	}
+
+	if m.Files != nil {
+		return errReadOnlyGoldenFiles
+	}
+
+	t, err := m.makeTableQuery(ctx, tableName, dbName, nil)
+	if err != nil {
+		return err
+	}
+
+	data, err := t.dumpRows()
+	if err != nil {
+		return fmt.Errorf("failed to dump table %s of database %s: %w", tableName, dbName, err)
+	}
+
+	contents, err := encodeTable(data, filePath, m.CSV)
+	if err != nil {
+		return err
+	}
+
+	filePath = filepath.FromSlash(m.resolveFilePath(ctx, filePath))
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil { // nolint:gosec // Golden files are committed.
+		return fmt.Errorf("failed to update golden file: %w", err)
+	}
+
+	if err := os.WriteFile(filePath, contents, 0o644); err != nil { // nolint:gosec // Golden files are committed.
+		return fmt.Errorf("failed to update golden file: %w", err)
+	}
+
+	return nil
+}
+
+// dumpRows reads all rows of table in Instance.OrderBy order with header of column names.
+func (t *tableQuery) dumpRows() (data [][]string, err error) {
+	qb := t.storage.SelectStmt(t.table, t.row)
+
+	if t.orderBy != "" {
+		qb = qb.OrderBy(t.orderBy)
+	}
+
+	rows, err := t.storage.Query(t.ctx, qb)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	data = [][]string{cols}
+
+	for rows.Next() {
+		values, err := scanRow(rows, len(cols))
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([]string, 0, len(cols))
+
+		for _, v := range values {
+			// Sub-second precision is kept to match the value on assertion.
+			if tm, ok := v.(time.Time); ok {
+				row = append(row, tm.Format(time.RFC3339Nano))
+
+				continue
+			}
+
+			cell, err := t.mapper.encodeCell(v)
+			if err != nil {
+				return nil, err
+			}
+
+			row = append(row, cell)
+		}
+
+		data = append(data, row)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// encodeTable renders rows as CSV (.csv, .tsv) or gherkin table (.table) according to file extension.
+func encodeTable(data [][]string, filePath string, dialect CSVDialect) ([]byte, error) {
+	buf := bytes.NewBuffer(nil)
+	ext := strings.ToLower(path.Ext(filePath))
+
+	switch ext {
+	case ".csv", ".tsv":
+		w := csv.NewWriter(buf)
+
+		if dialect.Comma != 0 {
+			w.Comma = dialect.Comma
+		}
+
+		if ext == ".tsv" {
+			w.Comma = '\t'
+		}
+
+		if dialect.Null != "" && dialect.Null != null {
+			for j := 1; j < len(data); j++ {
+				for i, cell := range data[j] {
+					if cell == null {
+						data[j][i] = dialect.Null
+					}
+				}
+			}
+		}
+
+		if err := w.WriteAll(data); err != nil {
+			return nil, err
+		}
+	case ".table":
+		width := make([]int, len(data[0]))
+
+		for _, row := range data {
+			for i, cell := range row {
+				row[i] = strings.ReplaceAll(strings.ReplaceAll(cell, `\`, `\\`), "|", `\|`)
+
+				if len(row[i]) > width[i] {
+					width[i] = len(row[i])
+				}
+			}
+		}
+
+		for _, row := range data {
+			buf.WriteString("|")
+
+			for i, cell := range row {
+				buf.WriteString(" " + cell + strings.Repeat(" ", width[i]-len(cell)) + " |")
+			}
+
+			buf.WriteString("\n")
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnsupportedGoldenFormat, filePath)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -16,6 +16,16 @@
 //			},
 //		}
 //
+// Instead of Go struct a table can be described with dbdog.Row, a map of column names to type hints.
+//
+//		"my_table": dbdog.Row{"id": 0, "name": nil, "created_at": time.Time{}, "meta": repository.Meta{}},
+//
+// Tables that are not registered in Instance.Tables can be resolved from database schema
+// (information_schema or sqlite pragma_table_info) by enabling Instance.Introspect.
+//
+// Auto increment sequences of a table can be restarted after rows are deleted by enabling Instance.ResetIdentity,
+// statements depend on driver name of Instance.Storage (postgres, pgx, mysql, sqlite).
+//
 // Table TableMapper Configuration
 //
 // Table mapper allows customizing decoding string values from godog table cells into Go row structures and back.
@@ -49,6 +59,13 @@
 //
 //   	Given there are no rows in table "my_table" of database "my_db"
 //
+// Delete all rows from multiple tables or from all tables of Instance.Tables, tables that reference other tables with
+// foreign keys are cleaned first, see Instance.Dependencies.
+//
+//   	Given there are no rows in tables "my_table, my_another_table" of database "my_db"
+//
+//   	Given there are no rows in database "my_db"
+//
 // Populate rows in a database with a gherkin table.
 //
 //	   And these rows are stored in table "my_table" of database "my_db"
@@ -64,6 +81,98 @@
 //		 path/to/rows.csv
 //		 """
 //
+// CSV files are comma-separated by default, .tsv files are tab-separated, delimiter, comment character,
+// lazy quotes, trimming of leading space and NULL token can be configured with Manager.CSV.
+//
+// Files with .json (array of objects) and .ndjson or .jsonl (object per line) extensions are read as JSON,
+// nested objects and arrays are kept as JSON cells, .table files are read as gherkin tables, other files are read
+// as CSV.
+//
+// File paths are relative to working directory, they can be served from Manager.Files file system (e.g. embed.FS)
+// with Manager.FilesDir base directory or resolved relative to feature file with Manager.FilesRelativeToFeature.
+//
+// Populate multiple tables from a YAML file with a mapping of table names to lists of rows, tables
+// of another database can be defined in a nested mapping keyed by database name.
+//
+//	   And data from this file is stored in database "my_db"
+//		 """
+//		 path/to/data.yaml
+//		 """
+//
+// Load a named fixture from Manager.Fixtures file system. Fixture is a directory with CSV, TSV, JSON or gherkin
+// table files named by tables, it can include other fixtures with an include.txt file. Tables are populated
+// in order of table dependencies.
+//
+//	   And fixture "users/basic" is loaded into database "my_db"
+//
+// Rows can also be defined with a JSON array of objects, object keys are columns, null is NULL, nested objects
+// and arrays are JSON cells. JSON variants are available for "these rows are stored" and "these rows are available"
+// steps.
+//
+//	   And these rows are stored in table "my_table" of database "my_db" as JSON:
+//		 """
+//		 [
+//		   {"id": 1, "foo": "foo-1", "bar": "abc", "created_at": "2021-01-01T00:00:00Z", "deleted_at": null}
+//		 ]
+//		 """
+//
+// Execute raw SQL statement, variables in statement are bound as arguments. Variables are not replaced in quoted
+// strings, quoted identifiers and dollar-quoted strings. Optional "expecting N affected" suffix asserts number
+// of affected rows.
+//
+//	   And I execute SQL in database "my_db" expecting 1 affected:
+//		 """
+//		 UPDATE my_table SET deleted_at = NOW() WHERE id = $id1
+//		 """
+//
+// Assert result of arbitrary SQL query, query is defined in a separate step with a docstring.
+// Rows are compared in order of query result, NULL, variables and JSON values are handled as in row assertions.
+//
+//	   Given SQL query in database "my_db" is:
+//		 """
+//		 SELECT foo, COUNT(1) AS cnt FROM my_table GROUP BY foo ORDER BY foo
+//		 """
+//
+//	   Then the result of SQL query in database "my_db" matches:
+//		 | foo   | cnt |
+//		 | foo-1 | 2   |
+//		 | foo-2 | 1   |
+//
+// Store values of SQL query result in variables to use them in next steps. Variables are populated from the first
+// row of result in order of columns.
+//
+//	   When I store SQL query result in database "my_db" into variables $total, $lastID:
+//		 """
+//		 SELECT COUNT(1), MAX(id) FROM my_table
+//		 """
+//
+// Alternatively variables can be mapped to columns and rows of result of a query defined with "SQL query is" step.
+//
+//	   And I store the result of SQL query in database "my_db" into variables:
+//		 | foo   | cnt   |
+//		 | $foo1 | $cnt1 |
+//		 | $foo2 | $cnt2 |
+//
+// Update existing rows in a database, table header contains key columns (comma-separated in step)
+// and columns to set. Step fails if a key does not match any row.
+//
+//	   And these rows are updated in table "my_table" of database "my_db" by key "id"
+//		 | id | bar | deleted_at           |
+//		 | 1  | xyz | 2021-01-04T00:00:00Z |
+//
+// Delete particular rows from a database. Each row of gherkin table is a WHERE condition that is built in the same
+// way as for "these rows are available": JSON values and unset variables are excluded, set variables are replaced.
+// Optional "expecting N deleted" suffix asserts total number of deleted rows.
+//
+//	   And these rows are deleted from table "my_table" of database "my_db" expecting 2 deleted
+//		 | foo   | deleted_at |
+//		 | foo-1 | NULL       |
+//
+// Assert table contents exhaustively with a golden file (.csv, .tsv or .table with gherkin table),
+// with enabled Manager.UpdateGolden or DBDOG_UPDATE_GOLDEN=1 env var table contents are written to golden file.
+//
+//	   Then table "my_table" of database "my_db" matches golden file "path/to/my_table.csv"
+//
 // Assert rows existence in a database.
 //
 // For each row in gherkin table DB is queried to find a row with WHERE condition that includes
@@ -78,6 +187,10 @@
 // Variables can help to assert consistency of dynamic data, for example variable can be populated as ID of one entity
 // and then checked as foreign key value of another entity. This can be especially helpful in cases of UUIDs.
 //
+// If a row is not found, error contains a diff of expected row and the closest table row with mismatched cells
+// marked with ^. By default assertion stops at the first failed row, with Manager.CollectRowErrors enabled all rows
+// are checked and failures of every row are reported in one error.
+//
 // If column value represents JSON array or object it is excluded from WHERE condition, value assertion is done
 // by comparing Go value mapped from database row field with Go value mapped from gherkin table cell.
 //
@@ -110,23 +223,88 @@
 //		 path/to/rows.csv
 //		 """
 //
+// Rows order can be checked by adding "in this order" to step statement. Rows are selected with ORDER BY expression
+// from Instance.OrderBy or from optional "by" clause of step and compared with gherkin table row by row.
+// Without "only" gherkin rows are compared with the first rows of ordered table, it does not look for
+// a sequence of matching rows further in the table.
+//
+//	   Then only these rows are available in table "my_table" of database "my_db" in this order by "created_at DESC"
+//		 | id   | foo   | bar | created_at           | deleted_at           |
+//		 | $id3 | foo-2 | hij | 2021-01-03T00:00:00Z | 2021-01-03T00:00:00Z |
+//		 | $id2 | foo-1 | def | 2021-01-02T00:00:00Z | 2021-01-03T00:00:00Z |
+//		 | $id1 | foo-1 | abc | 2021-01-01T00:00:00Z | NULL                 |
+//
 // Assert no rows exist in a database.
 //
 //	   And no rows are available in table "my_another_table" of database "my_db"
+//
+// Assert specific rows do not exist in a database, step fails if any of rows is found.
+// WHERE condition is built in the same way as for "these rows are available".
+//
+//	   And these rows are not available in table "my_table" of database "my_db"
+//		 | id | foo   |
+//		 | 1  | foo-1 |
+//
+// Rows can be also loaded from CSV file.
+//
+//	   And rows from this file are not available in table "my_table" of database "my_db"
+//		 """
+//		 path/to/rows.csv
+//		 """
+//
+// Assertions can be repeated until they pass or timeout is reached, this is useful for data that is written
+// asynchronously. Default timeout and delays between attempts are configured with Manager.Retry and Instance.Retry.
+// Eventual variants are available for "(only) these rows are available", "(only) rows from this file are available"
+// and "no rows are available" steps.
+//
+//	   Then eventually these rows are available in table "my_table" of database "my_db" within 5s
+//		 | id   | foo   | bar | created_at           | deleted_at           |
+//		 | $id1 | foo-1 | abc | 2021-01-01T00:00:00Z | NULL                 |
+//
+//	   And eventually no rows are available in table "my_another_table" of database "my_db"
+//
+// Scenario Isolation
+//
+// Instance can be configured to run every scenario in a transaction that is rolled back after scenario,
+// so that scenario leaves no trace in database and does not need explicit cleanup.
+//
+//		dbm.Instances = map[string]dbdog.Instance{
+//			"my_db": {
+//				Storage:       storage,
+//				Tables:        tables,
+//				Transactional: true,
+//			},
+//		}
+//
+// Alternatively tables that were populated during scenario can be cleaned after scenario with Instance.AutoCleanup.
+//
+// Contents of all tables registered in Instance.Tables can be kept in a named in-memory snapshot
+// and restored in later scenarios, so that expensive setup is done once per feature.
+//
+//   	Given database "my_db" snapshot "baseline" is taken
+//
+//   	Given database "my_db" is restored to snapshot "baseline"
 package dbdog
 
 import (
 	"context"
-	"encoding/csv"
+	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/bool64/ctxd"
 	"github.com/bool64/shared"
 	"github.com/bool64/sqluct"
 	"github.com/cucumber/godog"
@@ -141,6 +319,11 @@ const DefaultDatabase = "default"
 // RegisterSteps adds database manager context to test suite.
 func (m *Manager) RegisterSteps(s *godog.ScenarioContext) {
 	m.registerPrerequisites(s)
+	m.registerSQL(s)
+	m.registerSnapshots(s)
+	m.registerFixtures(s)
+	m.registerGolden(s)
+	m.registerEventualAssertions(s)
 	m.registerAssertions(s)
 	s.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
 		if m.Vars == nil {
@@ -149,85 +332,236 @@ func (m *Manager) RegisterSteps(s *godog.ScenarioContext) {
 
 		m.Vars.Reset()
 
-		return ctx, nil
+		ctx = context.WithValue(ctx, touchedTablesCtxKey{}, &touchedTables{})
+		ctx = context.WithValue(ctx, featureFileCtxKey{}, sc.Uri)
+
+		return m.beginTransactions(ctx)
+	})
+	s.After(func(ctx context.Context, sc *godog.Scenario, err error) (context.Context, error) {
+		// Transactions are rolled back even if cleanup fails to release connections.
+		cleanupErr := m.cleanupTables(ctx)
+		rollbackErr := rollbackTransactions(ctx)
+
+		switch {
+		case cleanupErr != nil && rollbackErr != nil:
+			return ctx, fmt.Errorf("%w, failed to rollback: %v", cleanupErr, rollbackErr)
+		case cleanupErr != nil:
+			return ctx, cleanupErr
+		default:
+			return ctx, rollbackErr
+		}
 	})
 }
 
+type txsCtxKey struct{}
+
+// beginTransactions starts scenario transactions for transactional instances and adds them to context.
+func (m *Manager) beginTransactions(ctx context.Context) (context.Context, error) {
+	txs := make(map[string]*sqlx.Tx)
+
+	for dbName, instance := range m.Instances {
+		if !instance.Transactional {
+			continue
+		}
+
+		tx, err := instance.Storage.DB().BeginTxx(ctx, nil)
+		if err != nil {
+			// Already started transactions are discarded to release connections.
+			for _, started := range txs {
+				_ = started.Rollback()
+			}
+
+			return ctx, fmt.Errorf("failed to begin transaction in db %s: %w", dbName, err)
+		}
+
+		txs[dbName] = tx
+	}
+
+	return context.WithValue(ctx, txsCtxKey{}, txs), nil
+}
+
+// rollbackTransactions discards changes made by scenario in transactional instances.
+func rollbackTransactions(ctx context.Context) error {
+	txs, _ := ctx.Value(txsCtxKey{}).(map[string]*sqlx.Tx)
+
+	var err error
+
+	for dbName, tx := range txs {
+		if rbErr := tx.Rollback(); rbErr != nil && err == nil {
+			err = fmt.Errorf("failed to rollback transaction in db %s: %w", dbName, rbErr)
+		}
+	}
+
+	return err
+}
+
+// instanceCtx returns context for database calls, it carries scenario transaction of transactional instance.
+func instanceCtx(ctx context.Context, dbName string) context.Context {
+	txs, _ := ctx.Value(txsCtxKey{}).(map[string]*sqlx.Tx)
+
+	if tx, ok := txs[dbName]; ok {
+		ctx = sqluct.TxToContext(ctx, tx)
+	}
+
+	return ctx
+}
+
 func (m *Manager) registerPrerequisites(s *godog.ScenarioContext) {
 	s.Step(`no rows in table "([^"]*)" of database "([^"]*)"$`,
 		m.noRowsInTableOfDatabase)
 
+	s.Step(`no rows in tables "([^"]*)" of database "([^"]*)"$`,
+		func(ctx context.Context, tableNames, database string) error {
+			return m.noRowsInTablesOfDatabase(ctx, splitTables(tableNames), database)
+		})
+
+	s.Step(`no rows in database "([^"]*)"$`,
+		m.noRowsInAllTablesOfDatabase)
+
+	s.Step(`no rows in tables "([^"]*)"$`,
+		func(ctx context.Context, tableNames string) error {
+			return m.noRowsInTablesOfDatabase(ctx, splitTables(tableNames), DefaultDatabase)
+		})
+
 	s.Step(`no rows in table "([^"]*)"$`,
-		func(tableName string) error {
-			return m.noRowsInTableOfDatabase(tableName, DefaultDatabase)
+		func(ctx context.Context, tableName string) error {
+			return m.noRowsInTableOfDatabase(ctx, tableName, DefaultDatabase)
 		})
 
 	s.Step(`these rows are stored in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, data *godog.Table) error {
-			return m.theseRowsAreStoredInTableOfDatabase(tableName, database, Rows(data))
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, database, Rows(data))
+		})
+
+	s.Step(`these rows are stored in table "([^"]*)" of database "([^"]*)" as JSON[:]?$`,
+		func(ctx context.Context, tableName, database string, rows *godog.DocString) error {
+			return m.theseJSONRowsAreStoredInTableOfDatabase(ctx, tableName, database, rows.Content)
+		})
+
+	s.Step(`these rows are stored in table "([^"]*)" as JSON[:]?$`,
+		func(ctx context.Context, tableName string, rows *godog.DocString) error {
+			return m.theseJSONRowsAreStoredInTableOfDatabase(ctx, tableName, DefaultDatabase, rows.Content)
 		})
 
 	s.Step(`rows from this file are stored in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreStoredInTableOfDatabase(tableName, database, filePath.Content)
+		func(ctx context.Context, tableName, database string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreStoredInTableOfDatabase(ctx, tableName, database, filePath.Content)
+		})
+
+	s.Step(`these rows are updated in table "([^"]*)" of database "([^"]*)" by key "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database, key string, data *godog.Table) error {
+			return m.theseRowsAreUpdatedInTableOfDatabase(ctx, tableName, database, key, Rows(data))
+		})
+
+	s.Step(`these rows are deleted from table "([^"]*)" of database "([^"]*)"(?: expecting (\d+) deleted)?[:]?$`,
+		func(ctx context.Context, tableName, database, expected string, data *godog.Table) error {
+			return m.theseRowsAreDeletedFromTableOfDatabase(ctx, tableName, database, expected, Rows(data))
 		})
 
 	s.Step(`these rows are stored in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.theseRowsAreStoredInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
 		})
 
 	s.Step(`rows from this file are stored in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreStoredInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreStoredInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
+		})
+
+	s.Step(`these rows are updated in table "([^"]*)" by key "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, key string, data *godog.Table) error {
+			return m.theseRowsAreUpdatedInTableOfDatabase(ctx, tableName, DefaultDatabase, key, Rows(data))
+		})
+
+	s.Step(`these rows are deleted from table "([^"]*)"(?: expecting (\d+) deleted)?[:]?$`,
+		func(ctx context.Context, tableName, expected string, data *godog.Table) error {
+			return m.theseRowsAreDeletedFromTableOfDatabase(ctx, tableName, DefaultDatabase, expected, Rows(data))
 		})
 }
 
 func (m *Manager) registerAssertions(s *godog.ScenarioContext) {
+	s.Step(`(only )?these rows are available in table "([^"]*)" of database "([^"]*)" in this order(?: by "([^"]*)")?[:]?$`,
+		func(ctx context.Context, only, tableName, database, orderBy string, data *godog.Table) error {
+			return m.assertOrderedRows(ctx, tableName, database, orderBy, Rows(data), only != "")
+		})
+
+	s.Step(`(only )?these rows are available in table "([^"]*)" in this order(?: by "([^"]*)")?[:]?$`,
+		func(ctx context.Context, only, tableName, orderBy string, data *godog.Table) error {
+			return m.assertOrderedRows(ctx, tableName, DefaultDatabase, orderBy, Rows(data), only != "")
+		})
+
+	s.Step(`(only )?these rows are available in table "([^"]*)" of database "([^"]*)" as JSON[:]?$`,
+		func(ctx context.Context, only, tableName, database string, rows *godog.DocString) error {
+			return m.assertJSONRows(ctx, tableName, database, rows.Content, only != "")
+		})
+
+	s.Step(`(only )?these rows are available in table "([^"]*)" as JSON[:]?$`,
+		func(ctx context.Context, only, tableName string, rows *godog.DocString) error {
+			return m.assertJSONRows(ctx, tableName, DefaultDatabase, rows.Content, only != "")
+		})
+
 	s.Step(`only rows from this file are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, filePath *godog.DocString) error {
-			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, database, filePath.Content)
+		func(ctx context.Context, tableName, database string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, database, filePath.Content)
 		})
 
 	s.Step(`only these rows are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, data *godog.Table) error {
-			return m.onlyTheseRowsAreAvailableInTableOfDatabase(tableName, database, Rows(data))
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
 		})
 
 	s.Step(`only rows from this file are available in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
 		})
 
 	s.Step(`only these rows are available in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.onlyTheseRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.onlyTheseRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
+		})
+
+	s.Step(`rows from this file are not available in table "([^"]*)" of database "([^"]*)"[:]?$`,
+		m.rowsFromThisFileAreNotAvailableInTableOfDatabase)
+
+	s.Step(`these rows are not available in table "([^"]*)" of database "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreNotAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
+		})
+
+	s.Step(`rows from this file are not available in table "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreNotAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
+		})
+
+	s.Step(`these rows are not available in table "([^"]*)"[:]?$`,
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreNotAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
 		})
 
 	s.Step(`no rows are available in table "([^"]*)" of database "([^"]*)"$`,
 		m.noRowsAreAvailableInTableOfDatabase)
 
 	s.Step(`no rows are available in table "([^"]*)"$`,
-		func(tableName string) error {
-			return m.noRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase)
+		func(ctx context.Context, tableName string) error {
+			return m.noRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase)
 		})
 
 	s.Step(`rows from this file are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
 		m.rowsFromThisFileAreAvailableInTableOfDatabase)
 
 	s.Step(`these rows are available in table "([^"]*)" of database "([^"]*)"[:]?$`,
-		func(tableName, database string, data *godog.Table) error {
-			return m.theseRowsAreAvailableInTableOfDatabase(tableName, database, Rows(data))
+		func(ctx context.Context, tableName, database string, data *godog.Table) error {
+			return m.theseRowsAreAvailableInTableOfDatabase(ctx, tableName, database, Rows(data))
 		})
 
 	s.Step(`rows from this file are available in table "([^"]*)"[:]?$`,
-		func(tableName string, filePath *godog.DocString) error {
-			return m.rowsFromThisFileAreAvailableInTableOfDatabase(tableName, DefaultDatabase, filePath.Content)
+		func(ctx context.Context, tableName string, filePath *godog.DocString) error {
+			return m.rowsFromThisFileAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, filePath.Content)
 		})
 
 	s.Step(`these rows are available in table "([^"]*)"[:]?$`,
-		func(tableName string, data *godog.Table) error {
-			return m.theseRowsAreAvailableInTableOfDatabase(tableName, DefaultDatabase, Rows(data))
+		func(ctx context.Context, tableName string, data *godog.Table) error {
+			return m.theseRowsAreAvailableInTableOfDatabase(ctx, tableName, DefaultDatabase, Rows(data))
 		})
 }
 
@@ -246,18 +580,77 @@ type Manager struct {
 
 	// Vars allow sharing vars with other steps.
 	Vars *shared.Vars
+
+	// Retry is a default policy of eventual assertions, it can be overridden with Instance.Retry.
+	Retry RetryPolicy
+
+	// Logger receives messages about automatic cleanup, optional.
+	Logger ctxd.Logger
+
+	// CollectRowErrors enables checking all rows in assertions and reporting all failed rows in one error,
+	// by default assertion stops at the first failed row.
+	CollectRowErrors bool
+
+	// Files is a file system of CSV files for "rows from this file" steps, e.g. embed.FS, OS file system is used if nil.
+	Files fs.FS
+
+	// FilesDir is a base directory of file paths in "rows from this file" steps.
+	FilesDir string
+
+	// FilesRelativeToFeature enables resolving file paths in "rows from this file" steps
+	// relative to directory of feature file, it takes precedence over FilesDir.
+	FilesRelativeToFeature bool
+
+	// CSV configures reading of CSV files, default is comma-separated file with NULL token.
+	CSV CSVDialect
+
+	// UpdateGolden enables writing table contents to golden files in "matches golden file" steps instead of
+	// asserting, it can also be enabled with DBDOG_UPDATE_GOLDEN=1 environment variable.
+	// Golden files are written to OS file system, so update fails if Files is configured.
+	UpdateGolden bool
+
+	// Fixtures is a file system with named fixtures for "fixture is loaded" step, e.g. os.DirFS("testdata/fixtures").
+	// Fixture is a directory with CSV, TSV, JSON or gherkin table (.table) files of table rows named by tables
+	// (users.csv) and an optional include.txt with names of included fixtures, one per line.
+	Fixtures fs.FS
+
+	snapshots    snapshots
+	introspected introspectedTables
 }
 
 // Instance provides database instance.
 type Instance struct {
 	Storage *sqluct.Storage
 	// Tables is a map of row structures per table name.
-	// Example: `"my_table": new(MyEntityRow)`
+	// Example: `"my_table": new(MyEntityRow)` or `"my_table": dbdog.Row{"id": 0, "name": ""}`
 	Tables map[string]interface{}
 	// PostNoRowsStatements is a map of SQL statement list per table name.
 	// They are executed after `no rows in table` step.
 	// Example: `"my_table": []string{"ALTER SEQUENCE my_table_id_seq RESTART"}`.
 	PostCleanup map[string][]string
+	// Transactional enables running each scenario in a transaction that is rolled back after scenario.
+	Transactional bool
+	// Retry overrides non-zero fields of Manager.Retry for eventual assertions.
+	Retry RetryPolicy
+	// AutoCleanup enables deleting rows from tables that were populated during scenario after scenario.
+	// Tables are cleaned in reverse order of population adjusted with Dependencies,
+	// PostCleanup statements are applied.
+	AutoCleanup bool
+	// ResetIdentity enables restarting auto increment sequences of a table after rows are deleted
+	// in "no rows in table" steps and AutoCleanup, supported drivers are postgres, pgx, mysql and sqlite.
+	// It can not be used with Transactional, because reset is not rolled back and MySQL commits implicitly.
+	ResetIdentity bool
+	// Dependencies is a map of referenced (parent) tables per table name, used to delete rows from
+	// multiple tables in order of foreign keys. If table is missing in Dependencies and Introspect is enabled,
+	// references are taken from database schema.
+	// Example: `"my_table": []string{"my_parent_table"}`.
+	Dependencies map[string][]string
+	// Introspect enables resolving tables that are missing in Tables by reading database schema,
+	// row structure of such table is built at runtime.
+	Introspect bool
+	// OrderBy is a map of ORDER BY expressions per table name, used by ordered assertions.
+	// Example: `"my_table": "created_at, id"`.
+	OrderBy map[string]string
 }
 
 // RegisterJSONTypes registers types of provided values to unmarshal as JSON when decoding from string.
@@ -277,30 +670,39 @@ func (m *Manager) RegisterJSONTypes(values ...interface{}) {
 	}
 }
 
-func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
-	instance, ok := m.Instances[dbName]
-	if !ok {
-		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+func (m *Manager) noRowsInTableOfDatabase(ctx context.Context, tableName, dbName string) error {
+	instance, _, err := m.instanceTable(ctx, tableName, dbName)
+	if err != nil {
+		return err
 	}
 
-	_, ok = instance.Tables[tableName]
-	if !ok {
-		return fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+	// Reset statements are not transactional (setval) or commit implicitly (ALTER TABLE in MySQL),
+	// so they would leak changes of a transactional scenario.
+	if instance.ResetIdentity && instance.Transactional {
+		return fmt.Errorf("%w: %s", errTransactionalResetIdentity, dbName)
 	}
 
+	ctx = instanceCtx(ctx, dbName)
+
 	// Deleting from table
-	_, err := instance.Storage.Exec(
-		context.Background(),
+	_, err = instance.Storage.Exec(
+		ctx,
 		instance.Storage.DeleteStmt(tableName),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to delete from table %s in db %s: %w", tableName, dbName, err)
 	}
 
+	if instance.ResetIdentity {
+		if err := resetIdentity(ctx, instance.Storage, tableName); err != nil {
+			return fmt.Errorf("failed to reset identity of table %s in db %s: %w", tableName, dbName, err)
+		}
+	}
+
 	if instance.PostCleanup != nil {
 		for _, statement := range instance.PostCleanup[tableName] {
 			_, err := instance.Storage.Exec(
-				context.Background(),
+				ctx,
 				sqluct.StringStatement(statement),
 			)
 			if err != nil {
@@ -315,31 +717,53 @@ func (m *Manager) noRowsInTableOfDatabase(tableName, dbName string) error {
 
 var errMissingFileName = errors.New("missing file name")
 
-func loadTableFromFile(filePath string) (rows [][]string, err error) {
-	if filePath == "" {
-		return nil, errMissingFileName
-	}
+type featureFileCtxKey struct{}
 
-	f, err := os.Open(filePath) // nolint:gosec // Intended file inclusion.
+// loadTableFromFile reads rows from Manager.Files or OS file system, see readTable for supported formats.
+func (m *Manager) loadTableFromFile(ctx context.Context, filePath string) (rows [][]string, err error) {
+	f, err := m.openFile(ctx, filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	defer func() { // nolint:gosec // False positive: G307: Deferring unsafe method "Close" on type "*os.File" (gosec)
+	defer func() {
 		clErr := f.Close()
 		if clErr != nil && err == nil {
 			err = clErr
 		}
 	}()
 
-	c := csv.NewReader(f)
+	return readTable(f, filePath, m.CSV)
+}
 
-	rows, err = c.ReadAll()
-	if err != nil {
-		return nil, fmt.Errorf("failed to read CSV: %w", err)
+// openFile opens a file from Manager.Files or OS file system.
+func (m *Manager) openFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if filePath == "" {
+		return nil, errMissingFileName
+	}
+
+	filePath = m.resolveFilePath(ctx, filePath)
+
+	if m.Files != nil {
+		return m.Files.Open(path.Clean(filePath))
+	}
+
+	return os.Open(filePath) // nolint:gosec // Intended file inclusion.
+}
+
+// resolveFilePath prepends directory of feature file or Manager.FilesDir to a file path.
+func (m *Manager) resolveFilePath(ctx context.Context, filePath string) string {
+	if m.FilesRelativeToFeature {
+		if featureFile, ok := ctx.Value(featureFileCtxKey{}).(string); ok && featureFile != "" {
+			return path.Join(path.Dir(filepath.ToSlash(featureFile)), filePath)
+		}
 	}
 
-	return rows, nil
+	if m.FilesDir != "" {
+		return path.Join(m.FilesDir, filePath)
+	}
+
+	return filePath
 }
 
 // Rows converts godog table to a nested slice of strings.
@@ -359,24 +783,33 @@ func Rows(data *godog.Table) [][]string {
 	return d
 }
 
-func (m *Manager) rowsFromThisFileAreStoredInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) rowsFromThisFileAreStoredInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	data, err := m.loadTableFromFile(ctx, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to load rows from file: %w", err)
 	}
 
-	return m.theseRowsAreStoredInTableOfDatabase(tableName, dbName, data)
+	return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, dbName, data)
 }
 
-func (m *Manager) theseRowsAreStoredInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	instance, ok := m.Instances[dbName]
-	if !ok {
-		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+func (m *Manager) theseJSONRowsAreStoredInTableOfDatabase(ctx context.Context, tableName, dbName, rows string) error {
+	data, err := readJSON(strings.NewReader(rows))
+	if err != nil {
+		return err
 	}
 
-	row, ok := instance.Tables[tableName]
-	if !ok {
-		return fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+	// Empty JSON array has no rows to store.
+	if len(data) < 2 {
+		return nil
+	}
+
+	return m.theseRowsAreStoredInTableOfDatabase(ctx, tableName, dbName, data)
+}
+
+func (m *Manager) theseRowsAreStoredInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	instance, row, err := m.instanceTable(ctx, tableName, dbName)
+	if err != nil {
+		return err
 	}
 
 	m.checkInit()
@@ -389,11 +822,13 @@ func (m *Manager) theseRowsAreStoredInTableOfDatabase(tableName, dbName string,
 
 	colNames := data[0]
 
+	trackTable(ctx, dbName, tableName)
+
 	storage := instance.Storage
 	stmt := storage.InsertStmt(tableName, rows, sqluct.Columns(colNames...))
 
 	// Inserting rows.
-	_, err = storage.Exec(context.Background(), stmt)
+	_, err = storage.Exec(instanceCtx(ctx, dbName), stmt)
 
 	if err != nil {
 		query, args, toSQLErr := stmt.ToSql()
@@ -407,34 +842,209 @@ func (m *Manager) theseRowsAreStoredInTableOfDatabase(tableName, dbName string,
 	return err
 }
 
-func (m *Manager) onlyRowsFromThisFileAreAvailableInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) theseRowsAreUpdatedInTableOfDatabase(ctx context.Context, tableName, dbName, key string, data [][]string) error {
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
+	if err != nil {
+		return err
+	}
+
+	keyCols := make([]string, 0, 1)
+	setCols := make([]string, 0, len(t.colNames))
+
+	for _, k := range strings.Split(key, ",") {
+		keyCols = append(keyCols, strings.TrimSpace(k))
+	}
+
+	for _, col := range t.colNames {
+		isKey := false
+
+		for _, k := range keyCols {
+			if k == col {
+				isKey = true
+
+				break
+			}
+		}
+
+		if !isKey {
+			setCols = append(setCols, col)
+		}
+	}
+
+	if len(t.colNames)-len(setCols) != len(keyCols) {
+		return fmt.Errorf("%w: %s", errMissingKeyColumn, key)
+	}
+
+	if len(setCols) == 0 {
+		return errMissingUpdateColumns
+	}
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	err = t.mapper.IterateTable(IterateConfig{
+		Data:     data,
+		Item:     t.row,
+		Replaces: replaces,
+		ReceiveRow: func(index int, row interface{}, _ []string, _ []string) error {
+			stmt := t.storage.UpdateStmt(tableName, row, sqluct.Columns(setCols...)).
+				Where(t.storage.WhereEq(row, sqluct.Columns(keyCols...)))
+
+			res, err := t.storage.Exec(t.ctx, stmt)
+			if err == nil {
+				var affected int64
+
+				affected, err = res.RowsAffected()
+				if err == nil && affected == 0 {
+					err = errNoRowsUpdated
+				}
+			}
+
+			if err != nil {
+				query, args, toSQLErr := stmt.ToSql()
+				if toSQLErr != nil {
+					return toSQLErr
+				}
+
+				return fmt.Errorf("failed to update row %d %q, %v: %w", index, query, args, err)
+			}
+
+			return nil
+		},
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	return err
+}
+
+func (m *Manager) theseRowsAreDeletedFromTableOfDatabase(ctx context.Context, tableName, dbName, expected string, data [][]string) error {
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
+	if err != nil {
+		return err
+	}
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	var deleted int64
+
+	err = t.mapper.IterateTable(IterateConfig{
+		Data:       data,
+		Item:       t.row,
+		SkipDecode: t.skipDecode,
+		Replaces:   replaces,
+		ReceiveRow: func(index int, row interface{}, _ []string, _ []string) error {
+			// JSON values can not be used in WHERE condition and are ignored.
+			t.postCheck = t.postCheck[:0]
+
+			conditions := t.rowConditions(row)
+			if len(conditions) == 0 {
+				return fmt.Errorf("%w: row %d", errNoConditions, index)
+			}
+
+			stmt := t.storage.DeleteStmt(tableName)
+			for _, c := range conditions {
+				stmt = stmt.Where(c)
+			}
+
+			res, err := t.storage.Exec(t.ctx, stmt)
+			if err == nil {
+				var affected int64
+
+				affected, err = res.RowsAffected()
+				deleted += affected
+			}
+
+			if err != nil {
+				query, args, toSQLErr := stmt.ToSql()
+				if toSQLErr != nil {
+					return toSQLErr
+				}
+
+				return fmt.Errorf("failed to delete row %d %q, %v: %w", index, query, args, err)
+			}
+
+			return nil
+		},
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	if err != nil || expected == "" {
+		return err
+	}
+
+	want, err := strconv.ParseInt(expected, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse expected number of deleted rows: %w", err)
+	}
+
+	if deleted != want {
+		return fmt.Errorf("%w: %d expected, %d deleted", errInvalidNumberOfDeletedRows, want, deleted)
+	}
+
+	return nil
+}
+
+func (m *Manager) onlyRowsFromThisFileAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	data, err := m.loadTableFromFile(ctx, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to load rows from file: %w", err)
 	}
 
-	return m.assertRows(tableName, dbName, data, true)
+	return m.assertRows(ctx, tableName, dbName, data, true)
 }
 
-func (m *Manager) onlyTheseRowsAreAvailableInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	return m.assertRows(tableName, dbName, data, true)
+func (m *Manager) onlyTheseRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	return m.assertRows(ctx, tableName, dbName, data, true)
 }
 
-func (m *Manager) noRowsAreAvailableInTableOfDatabase(tableName, dbName string) error {
-	return m.assertRows(tableName, dbName, nil, true)
+func (m *Manager) noRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string) error {
+	return m.assertRows(ctx, tableName, dbName, nil, true)
 }
 
-func (m *Manager) rowsFromThisFileAreAvailableInTableOfDatabase(tableName, dbName string, filePath string) error {
-	data, err := loadTableFromFile(filePath)
+func (m *Manager) rowsFromThisFileAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	data, err := m.loadTableFromFile(ctx, filePath)
 	if err != nil {
 		return fmt.Errorf("failed to load rows from file: %w", err)
 	}
 
-	return m.assertRows(tableName, dbName, data, false)
+	return m.assertRows(ctx, tableName, dbName, data, false)
+}
+
+func (m *Manager) assertJSONRows(ctx context.Context, tableName, dbName, rows string, exhaustiveList bool) error {
+	data, err := readJSON(strings.NewReader(rows))
+	if err != nil {
+		return err
+	}
+
+	// Empty JSON array means no rows are expected in exhaustive list and nothing to check otherwise.
+	if len(data) < 2 {
+		if exhaustiveList {
+			return m.noRowsAreAvailableInTableOfDatabase(ctx, tableName, dbName)
+		}
+
+		return nil
+	}
+
+	return m.assertRows(ctx, tableName, dbName, data, exhaustiveList)
 }
 
-func (m *Manager) theseRowsAreAvailableInTableOfDatabase(tableName, dbName string, data [][]string) error {
-	return m.assertRows(tableName, dbName, data, false)
+func (m *Manager) theseRowsAreAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) error {
+	return m.assertRows(ctx, tableName, dbName, data, false)
 }
 
 type testingT struct {
@@ -446,6 +1056,7 @@ func (t *testingT) Errorf(format string, args ...interface{}) {
 }
 
 type tableQuery struct {
+	ctx           context.Context
 	storage       *sqluct.Storage
 	mapper        *TableMapper
 	table         string
@@ -455,11 +1066,17 @@ type tableQuery struct {
 	skipWhereCols []string
 	postCheck     []string
 	vars          *shared.Vars
+	orderBy       string
+	collectErrors bool
 }
 
 func (t *tableQuery) exposeContents(err error) error {
 	qb := t.storage.SelectStmt(t.table, t.row).Limit(50)
 
+	if t.orderBy != "" {
+		qb = qb.OrderBy(t.orderBy)
+	}
+
 	var colNames []string
 
 	if t.data != nil {
@@ -491,7 +1108,7 @@ func (t *tableQuery) checkCount() error {
 		Count int `db:"c"`
 	}{}
 
-	err := t.storage.Select(context.Background(), qb, &cnt)
+	err := t.storage.Select(t.ctx, qb, &cnt)
 	if err != nil {
 		return err
 	}
@@ -504,26 +1121,25 @@ func (t *tableQuery) checkCount() error {
 	return nil
 }
 
-func (m *Manager) makeTableQuery(tableName, dbName string, data [][]string) (*tableQuery, error) {
-	instance, ok := m.Instances[dbName]
-	if !ok {
-		return nil, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
-	}
-
-	row, ok := instance.Tables[tableName]
-	if !ok {
-		return nil, fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+func (m *Manager) makeTableQuery(ctx context.Context, tableName, dbName string, data [][]string) (*tableQuery, error) {
+	instance, row, err := m.instanceTable(ctx, tableName, dbName)
+	if err != nil {
+		return nil, err
 	}
 
 	m.checkInit()
 
 	t := tableQuery{
+		ctx:     instanceCtx(ctx, dbName),
 		storage: instance.Storage,
 		mapper:  m.TableMapper,
 		table:   tableName,
 		data:    data,
 		row:     row,
 		vars:    m.Vars,
+		orderBy: instance.OrderBy[tableName],
+
+		collectErrors: m.CollectRowErrors,
 	}
 
 	if t.data != nil {
@@ -535,11 +1151,8 @@ func (m *Manager) makeTableQuery(tableName, dbName string, data [][]string) (*ta
 	return &t, nil
 }
 
-func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValues []string) error {
-	qb := t.storage.QueryBuilder().
-		Select(t.colNames...).
-		From(t.table)
-
+// rowConditions builds WHERE conditions of row values, skipped columns are excluded.
+func (t *tableQuery) rowConditions(row interface{}) []squirrel.Eq {
 	eq := t.storage.WhereEq(row, sqluct.Columns(t.colNames...))
 
 	for _, sk := range t.skipWhereCols {
@@ -548,37 +1161,102 @@ func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValue
 
 	t.skipWhereCols = t.skipWhereCols[:0]
 
+	conditions := make([]squirrel.Eq, 0, len(eq))
+
 	for _, col := range t.colNames {
 		if _, ok := eq[col]; !ok {
 			continue
 		}
 
-		qb = qb.Where(squirrel.Eq{col: eq[col]})
+		conditions = append(conditions, squirrel.Eq{col: eq[col]})
+	}
+
+	return conditions
+}
+
+// rowQuery builds a query to find a row by its values, skipped columns are excluded from WHERE condition.
+func (t *tableQuery) rowQuery(row interface{}) squirrel.SelectBuilder {
+	qb := t.storage.QueryBuilder().
+		Select(t.colNames...).
+		From(t.table)
+
+	for _, c := range t.rowConditions(row) {
+		qb = qb.Where(c)
 	}
 
+	return qb
+}
+
+func (t *tableQuery) receiveRow(index int, row interface{}, _ []string, rawValues []string) error {
+	pc := t.postCheck
+	t.postCheck = t.postCheck[:0]
+
+	qb := t.rowQuery(row)
+
 	dest := reflect.New(reflect.TypeOf(row).Elem()).Interface()
 
-	err := t.storage.Select(context.Background(), qb, dest)
+	err := t.storage.Select(t.ctx, qb, dest)
 	if err != nil {
 		query, args, qbErr := qb.ToSql()
 		if qbErr != nil {
 			return fmt.Errorf("failed to build query: %w", qbErr)
 		}
 
-		return fmt.Errorf("failed to query row %d (%+v) with %q %v: %w", index, row, query, args, err)
+		err = fmt.Errorf("failed to query row %d (%+v) with %q %v: %w", index, row, query, args, err)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			if diff, diffErr := t.closestRow(row, rawValues); diffErr != nil {
+				err = fmt.Errorf("%w, failed to find closest row: %v", err, diffErr)
+			} else if diff != "" {
+				err = fmt.Errorf("%w, closest row:\n%s", err, diff)
+			}
+		}
+
+		return err
 	}
 
 	colOption := sqluct.Columns(t.colNames...)
 
-	pc := t.postCheck
-	t.postCheck = t.postCheck[:0]
-
 	return t.doPostCheck(t.colNames, pc,
 		combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption)),
 		combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(dest), colOption)),
 		rawValues)
 }
 
+func (t *tableQuery) receiveAbsentRow(index int, row interface{}, _ []string, _ []string) error {
+	pc := t.postCheck
+	t.postCheck = t.postCheck[:0]
+
+	qb := t.rowQuery(row)
+
+	dest := reflect.New(reflect.SliceOf(reflect.TypeOf(row).Elem()))
+
+	err := t.storage.Select(t.ctx, qb, dest.Interface())
+	if err != nil {
+		query, args, qbErr := qb.ToSql()
+		if qbErr != nil {
+			return fmt.Errorf("failed to build query: %w", qbErr)
+		}
+
+		return fmt.Errorf("failed to query row %d (%+v) with %q %v: %w", index, row, query, args, err)
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+
+	argsExp := combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption))
+	found := dest.Elem()
+
+	for i := 0; i < found.Len(); i++ {
+		argsRcv := combine(t.storage.Mapper.ColumnsValues(found.Index(i).Addr(), colOption))
+
+		if checkColumns(pc, argsExp, argsRcv) == nil {
+			return fmt.Errorf("%w: row %d (%+v)", errRowAvailable, index, row)
+		}
+	}
+
+	return nil
+}
+
 func combine(keys []string, vals []interface{}) map[string]interface{} {
 	m := make(map[string]interface{}, len(keys))
 	for i, k := range keys {
@@ -642,8 +1320,8 @@ func (t *tableQuery) makeReplaces(onSetErr *error) (map[string]string, error) {
 	return replaces, nil
 }
 
-func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhaustiveList bool) (err error) {
-	t, err := m.makeTableQuery(tableName, dbName, data)
+func (m *Manager) assertRows(ctx context.Context, tableName, dbName string, data [][]string, exhaustiveList bool) (err error) {
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
 	if err != nil {
 		return err
 	}
@@ -655,14 +1333,18 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 		}
 	}()
 
+	return t.assertRows(exhaustiveList)
+}
+
+func (t *tableQuery) assertRows(exhaustiveList bool) error {
 	if exhaustiveList {
-		err = t.checkCount()
+		err := t.checkCount()
 		if err != nil {
 			return err
 		}
 	}
 
-	if data == nil {
+	if t.data == nil {
 		return nil
 	}
 
@@ -674,12 +1356,58 @@ func (m *Manager) assertRows(tableName, dbName string, data [][]string, exhausti
 	}
 
 	// Iterating rows.
-	err = m.TableMapper.IterateTable(IterateConfig{
+	err = t.mapper.IterateTable(IterateConfig{
+		Data:          t.data,
+		Item:          t.row,
+		SkipDecode:    t.skipDecode,
+		Replaces:      replaces,
+		ReceiveRow:    t.receiveRow,
+		CollectErrors: t.collectErrors,
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	return err
+}
+
+func (m *Manager) rowsFromThisFileAreNotAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, filePath string) error {
+	data, err := m.loadTableFromFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to load rows from file: %w", err)
+	}
+
+	return m.theseRowsAreNotAvailableInTableOfDatabase(ctx, tableName, dbName, data)
+}
+
+func (m *Manager) theseRowsAreNotAvailableInTableOfDatabase(ctx context.Context, tableName, dbName string, data [][]string) (err error) {
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose table contents to simplify test debugging.
+		if err != nil {
+			err = t.exposeContents(err)
+		}
+	}()
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	// Iterating rows.
+	err = t.mapper.IterateTable(IterateConfig{
 		Data:       data,
 		Item:       t.row,
 		SkipDecode: t.skipDecode,
 		Replaces:   replaces,
-		ReceiveRow: t.receiveRow,
+		ReceiveRow: t.receiveAbsentRow,
 	})
 
 	if err == nil && onSetErr != nil {
@@ -694,40 +1422,73 @@ func (t *tableQuery) doPostCheck(colNames []string, postCheck []string, argsExp,
 		if t.vars.IsVar(rawValues[i]) {
 			t.vars.Set(rawValues[i], argsRcv[name])
 		}
+	}
 
-		pc := false
-
-		for _, col := range postCheck {
-			if col == name {
-				pc = true
+	return checkColumns(postCheck, argsExp, argsRcv)
+}
 
-				break
-			}
-		}
+// checkColumns compares Go values of expected and received row in columns that are excluded from WHERE condition.
+func checkColumns(postCheck []string, argsExp, argsRcv map[string]interface{}) error {
+	for _, name := range postCheck {
+		exp, rcv := indirect(argsExp[name]), indirect(argsRcv[name])
 
-		if !pc {
+		if valuesEqual(exp, rcv) {
 			continue
 		}
 
 		te := testingT{}
 
-		assert.Equal(&te, indirect(argsExp[name]), indirect(argsRcv[name]))
+		assert.Equal(&te, exp, rcv)
 
-		if te.Err != nil {
-			return fmt.Errorf("unexpected row contents at column %s (%#v, %#v): %w",
-				name, indirect(argsExp[name]), indirect(argsRcv[name]), te.Err)
-		}
+		return fmt.Errorf("unexpected row contents at column %s (%#v, %#v): %w", name, exp, rcv, te.Err)
 	}
 
 	return nil
 }
 
+// valuesEqual compares values of row fields, time values are compared as instants regardless of location.
+func valuesEqual(exp, rcv interface{}) bool {
+	if assert.ObjectsAreEqual(exp, rcv) {
+		return true
+	}
+
+	if te, ok := exp.(time.Time); ok {
+		if tr, ok := rcv.(time.Time); ok {
+			return te.Equal(tr)
+		}
+	}
+
+	// Types like sql.NullTime are compared by their driver values.
+	if ve, ok := exp.(driver.Valuer); ok {
+		if vr, ok := rcv.(driver.Valuer); ok {
+			de, errE := ve.Value()
+			dr, errR := vr.Value()
+
+			if errE == nil && errR == nil {
+				if _, isValuer := de.(driver.Valuer); !isValuer {
+					return valuesEqual(de, dr)
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func indirect(v interface{}) interface{} {
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
 		rv = rv.Elem()
 	}
 
+	if !rv.IsValid() {
+		return nil
+	}
+
 	return rv.Interface()
 }
 
@@ -793,14 +1554,19 @@ func NewTableMapper() *TableMapper {
 }
 
 var (
-	errWrongType           = errors.New("failed to assert type *interface{}")
-	errInvalidNumberOfRows = errors.New("invalid number of rows in table")
-	errUnknownTable        = errors.New("unknown table")
-	errUnknownDatabase     = errors.New("unknown database")
+	errInvalidNumberOfRows        = errors.New("invalid number of rows in table")
+	errUnknownTable               = errors.New("unknown table")
+	errUnknownDatabase            = errors.New("unknown database")
+	errRowAvailable               = errors.New("unexpected row is available in table")
+	errNoRowsUpdated              = errors.New("no rows updated")
+	errMissingKeyColumn           = errors.New("key columns are missing in table header")
+	errMissingUpdateColumns       = errors.New("no columns to update in table header")
+	errNoConditions               = errors.New("no conditions to match rows")
+	errInvalidNumberOfDeletedRows = errors.New("invalid number of deleted rows")
 )
 
 func (t *tableQuery) queryExistingRows(db *sqluct.Storage, colNames []string, qb squirrel.Sqlizer) (table string, err error) {
-	rows, err := db.Query(context.Background(), qb)
+	rows, err := db.Query(t.ctx, qb)
 	if err != nil {
 		return "", err
 	}
@@ -840,12 +1606,12 @@ func (t *tableQuery) queryExistingRows(db *sqluct.Storage, colNames []string, qb
 		}
 	}
 
-	result := t.renderRows(colNames, res, width, cnt)
+	result := renderRows(colNames, res, width, cnt)
 
 	return result, rows.Err()
 }
 
-func (t *tableQuery) renderRows(colNames []string, res map[string][]string, width map[string]int, cnt int) string {
+func renderRows(colNames []string, res map[string][]string, width map[string]int, cnt int) string {
 	result := "|"
 
 	for _, col := range colNames {
@@ -878,41 +1644,15 @@ func (t *tableQuery) renderRows(colNames []string, res map[string][]string, widt
 }
 
 func (t *tableQuery) formatRow(rows *sqlx.Rows, cols []string, width map[string]int, res map[string][]string) error {
-	// Create a slice of interface{} to represent each column,
-	// and a second slice to contain pointers to each item in the columns slice.
-	columns := make([]interface{}, len(cols))
-	columnPointers := make([]interface{}, len(cols))
-
-	for i := range columns {
-		columnPointers[i] = &columns[i]
-	}
-
-	// Scan the result into the column pointers.
-	if err := rows.Scan(columnPointers...); err != nil {
+	values, err := scanRow(rows, len(cols))
+	if err != nil {
 		return err
 	}
 
-	// Create map and retrieve the value for each column from the pointers slice,
-	// storing it in the map with the name of the column as the key.
 	for i, col := range cols {
-		val, ok := columnPointers[i].(*interface{})
-		if !ok {
-			return fmt.Errorf("%w of %T", errWrongType, columnPointers[i])
-		}
-
-		var v string
-
-		if *val == nil {
-			v = null
-		} else if b, ok := (*val).([]byte); ok {
-			v = string(b)
-		} else {
-			s, err := t.mapper.Encode(*val)
-			if err != nil {
-				return err
-			}
-
-			v = s
+		v, err := t.mapper.encodeCell(values[i])
+		if err != nil {
+			return err
 		}
 
 		if len(v) > width[col] {
@@ -924,3 +1664,22 @@ func (t *tableQuery) formatRow(rows *sqlx.Rows, cols []string, width map[string]
 
 	return nil
 }
+
+// scanRow scans current row of result into a slice of values.
+func scanRow(rows *sqlx.Rows, numCols int) ([]interface{}, error) {
+	// Create a slice of interface{} to represent each column,
+	// and a second slice to contain pointers to each item in the columns slice.
+	columns := make([]interface{}, numCols)
+	columnPointers := make([]interface{}, numCols)
+
+	for i := range columns {
+		columnPointers[i] = &columns[i]
+	}
+
+	// Scan the result into the column pointers.
+	if err := rows.Scan(columnPointers...); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
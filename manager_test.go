@@ -2,12 +2,19 @@ package dbdog_test
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/bool64/ctxd"
 	"github.com/bool64/dbdog"
 	"github.com/bool64/sqluct"
 	"github.com/cucumber/godog"
@@ -232,3 +239,1924 @@ func TestManager_RegisterContext_fail(t *testing.T) {
 		t.Fatal(buf.String())
 	}
 }
+
+func TestManager_RegisterSteps_transactional(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			Transactional: true,
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM my_table`).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\)`).
+		WithArgs(1, "foo-1").
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+	mock.ExpectRollback()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Transactional.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_cleanupFailure(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	anotherDB, anotherMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			Transactional: true,
+		},
+		"my_another_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(anotherDB, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			AutoCleanup: true,
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	anotherMock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\)`).
+		WithArgs(1, "foo-1").
+		WillReturnResult(driver.ResultNoRows)
+	anotherMock.ExpectExec(`DELETE FROM my_table`).
+		WillReturnError(errors.New("failed"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/CleanupFailure.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, anotherMock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_context(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	type ctxKey struct{}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	var tracedValues []interface{}
+
+	storage := sqluct.NewStorage(sqlx.NewDb(db, "sqlmock"))
+	storage.Trace = func(ctx context.Context, stmt string, args []interface{}) (context.Context, func(error)) {
+		tracedValues = append(tracedValues, ctx.Value(ctxKey{}))
+
+		return ctx, func(error) {}
+	}
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: storage,
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`DELETE FROM my_table`).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES \(\$1,\$2\)`).
+		WithArgs(1, "foo-1").
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			s.Before(func(ctx context.Context, sc *godog.Scenario) (context.Context, error) {
+				return context.WithValue(ctx, ctxKey{}, "scenario"), nil
+			})
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Transactional.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, []interface{}{"scenario", "scenario", "scenario", "scenario"}, tracedValues)
+}
+
+func TestManager_RegisterSteps_eventually(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.Retry.Interval = time.Millisecond
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = \$1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1"))
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_another_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_another_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Eventually.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	id, found := dbm.Vars.Get("$id")
+	assert.True(t, found)
+	assert.Equal(t, 1, id)
+}
+
+func TestManager_RegisterSteps_notAvailable(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(1, "foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+		WithArgs(2, "foo-2").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(2, "foo-2"))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(2, "foo-2"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/NotAvailable.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), "unexpected row is available in table: row 1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterSteps_update(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`UPDATE my_table SET foo = \$1 WHERE id = \$2`).
+		WithArgs("foo-2", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE my_table SET foo = \$1 WHERE id = \$2`).
+		WithArgs("foo-3", 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Update.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), `failed to update row 0 "UPDATE my_table SET foo = $1 WHERE id = $2", [foo-3 2]: no rows updated`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterSteps_delete(t *testing.T) {
+	type row struct {
+		ID   int     `db:"id"`
+		Foo  *string `db:"foo"`
+		Meta *string `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`DELETE FROM my_table WHERE id = \$1 AND foo IS NULL$`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM my_table WHERE foo = \$1 AND meta IS NULL$`).
+		WithArgs("foo-2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Delete.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_ordered(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			OrderBy: map[string]string{
+				"my_table": "id DESC",
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table ORDER BY id DESC LIMIT 2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(2, "foo-2").AddRow(1, "foo-1"))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table ORDER BY id LIMIT 2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1").AddRow(2, "foo-2"))
+	mock.ExpectQuery(`SELECT id, foo FROM my_table ORDER BY id LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "foo-1").AddRow(2, "foo-2"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Ordered.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), "unexpected row at position 1")
+	assert.Contains(t, buf.String(), "unexpected row contents at column foo")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterSteps_orderedTime(t *testing.T) {
+	type row struct {
+		ID int       `db:"id"`
+		At time.Time `db:"at"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			OrderBy: map[string]string{
+				"my_table": "id",
+			},
+		},
+	}
+
+	at := mustParseTime("2021-01-01T01:00:00+01:00")
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, at FROM my_table ORDER BY id LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at"}).AddRow(1, at))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/OrderedTime.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_diff(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+		Bar string `db:"bar"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo, bar FROM my_table WHERE foo = \$1 AND bar = \$2`).
+		WithArgs("foo-1", "abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar"}))
+	mock.ExpectQuery(`SELECT id, foo, bar FROM my_table LIMIT 1000`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar"}).
+			AddRow(1, "foo-2", "def").
+			AddRow(2, "foo-1", "abd"))
+	mock.ExpectQuery(`SELECT id, foo, bar FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo", "bar"}).
+			AddRow(1, "foo-2", "def").
+			AddRow(2, "foo-1", "abd"))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Diff.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), `closest row:
+|   | id  | foo   | bar |
+| - | $id | foo-1 | abc |
+| + | 2   | foo-1 | abd |
+|   |     |       | ^^^ |
+`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterSteps_diffTime(t *testing.T) {
+	type row struct {
+		ID  int       `db:"id"`
+		At  time.Time `db:"at"`
+		Bar string    `db:"bar"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, at, bar FROM my_table WHERE at = \$1 AND bar = \$2`).
+		WithArgs(mustParseTime("2021-01-01T00:00:00Z"), "abc").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at", "bar"}))
+	mock.ExpectQuery(`SELECT id, at, bar FROM my_table LIMIT 1000`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at", "bar"}).
+			AddRow(1, mustParseTime("2021-02-01T00:00:00Z"), "abd").
+			AddRow(2, mustParseTime("2021-01-01T01:00:00+01:00"), "abd"))
+	mock.ExpectQuery(`SELECT id, at, bar FROM my_table LIMIT 50`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at", "bar"}))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/DiffTime.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.Contains(t, buf.String(), `closest row:
+|   | id  | at                        | bar |
+| - | $id | 2021-01-01T00:00:00Z      | abc |
+| + | 2   | 2021-01-01T01:00:00+01:00 | abd |
+|   |     |                           | ^^^ |
+`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	if status == 0 {
+		t.Fatal(buf.String())
+	}
+}
+
+func TestManager_RegisterSteps_sql(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE foo = \$1`).
+		WithArgs("foo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(12, "foo-1"))
+	mock.ExpectExec(`UPDATE my_table SET foo = 'bar\?' WHERE id = \$1`).
+		WithArgs(12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/SQL.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_sqlQuery(t *testing.T) {
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+		},
+	}
+
+	mock.ExpectQuery(`SELECT foo, COUNT\(1\) AS cnt, MAX\(created_at\) AS last, MAX\(meta\) AS meta FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"foo", "cnt", "last", "meta"}).
+			AddRow("foo-1", 2, mustParseTime("2021-01-02"), []byte(`{"a":1,"b":2}`)).
+			AddRow("foo-2", 1, nil, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/SQLQuery.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	cnt, found := dbm.Vars.Get("$cnt")
+	assert.True(t, found)
+	assert.Equal(t, int64(2), cnt)
+}
+
+func TestManager_RegisterSteps_sqlQuoted(t *testing.T) {
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+		},
+	}
+
+	mock.ExpectQuery(`SELECT 12`).
+		WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(12))
+	mock.ExpectExec(`CREATE FUNCTION touch() RETURNS trigger AS ` +
+		`$body$ BEGIN NEW.foo = '$id?'; RETURN NEW; END; $body$ LANGUAGE plpgsql`).
+		WithArgs().
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`UPDATE "my$table" SET foo = '$id', bar = 'it''s $id' WHERE id = $1`).
+		WithArgs(12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/SQLQuoted.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_sqlVars(t *testing.T) {
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\), MAX\(id\) FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"?column?", "?column?"}).AddRow(4, 12))
+	mock.ExpectQuery(`SELECT name FROM my_table WHERE id = \$1`).
+		WithArgs(12).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow([]byte("foo")))
+	mock.ExpectExec(`UPDATE my_table SET name = \$1 WHERE id < \$2`).
+		WithArgs("foo", 4).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/SQLVars.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_introspect(t *testing.T) {
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage:    sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Introspect: true,
+		},
+	}
+
+	mock.ExpectQuery(`SELECT column_name AS name, data_type AS type FROM information_schema.columns ` +
+		`WHERE table_name = \$1 AND table_schema = current_schema\(\) ORDER BY ordinal_position`).
+		WithArgs("users").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "type"}).
+			AddRow("id", "integer").
+			AddRow("name", "text").
+			AddRow("created_at", "timestamp with time zone"))
+	mock.ExpectExec(`INSERT INTO users \(id,name,created_at\) VALUES \(\$1,\$2,\$3\)`).
+		WithArgs(int64(1), "foo", mustParseTime("2021-01-01T00:00:00Z")).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectQuery(`SELECT id, name, created_at FROM users WHERE name = \$1 AND created_at = \$2`).
+		WithArgs("foo", mustParseTime("2021-01-01T00:00:00Z")).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at"}).
+			AddRow(1, "foo", mustParseTime("2021-01-01T00:00:00Z")))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Introspect.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// Introspected row structure is cached in Manager, configured instance is not modified.
+	assert.Nil(t, dbm.Instances["my_db"].Tables)
+}
+
+func TestManager_RegisterSteps_autoCleanup(t *testing.T) {
+	type row struct {
+		ID  int    `db:"id"`
+		Foo string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	logger := &ctxd.LoggerMock{}
+	dbm.Logger = logger
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table":         new(row),
+				"my_another_table": new(row),
+			},
+			PostCleanup: map[string][]string{
+				"my_table": {"ALTER SEQUENCE my_table_id_seq RESTART"},
+			},
+			AutoCleanup: true,
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO my_table`).WithArgs(1, "foo-1").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO my_another_table`).WithArgs(1, "foo-1").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO my_table`).WithArgs(2, "foo-2").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM my_another_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM my_table`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`ALTER SEQUENCE my_table_id_seq RESTART`).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/AutoCleanup.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, `info: cleaned up table {"database":"my_db","table":"my_another_table"}
+info: cleaned up table {"database":"my_db","table":"my_table"}
+`, logger.String())
+}
+
+func TestManager_RegisterSteps_dependencies(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"users":       new(row),
+				"products":    new(row),
+				"orders":      new(row),
+				"order_items": new(row),
+			},
+			Dependencies: map[string][]string{
+				"orders":      {"users"},
+				"order_items": {"orders", "products"},
+			},
+		},
+	}
+
+	mock.ExpectExec(`DELETE FROM order_items`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM orders`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectExec(`DELETE FROM order_items`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM orders`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM products`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Dependencies.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_dependenciesIntrospect(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "postgres")),
+			Tables: map[string]interface{}{
+				"shop.users":  new(row),
+				"shop.orders": new(row),
+			},
+			Introspect: true,
+		},
+	}
+
+	mock.ExpectQuery(`SELECT DISTINCT ccu.table_schema \|\| '.' \|\| ccu.table_name `+
+		`FROM information_schema.table_constraints tc JOIN information_schema.constraint_column_usage ccu .+ `+
+		`WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = \$1 AND tc.table_name = \$2`).
+		WithArgs("shop", "users").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	mock.ExpectQuery(`SELECT DISTINCT ccu.table_schema`).
+		WithArgs("shop", "orders").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("shop.users"))
+	mock.ExpectExec(`DELETE FROM shop.orders`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM shop.users`).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/DependenciesIntrospect.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_resetIdentity(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+	pg, pgMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	my, myMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	myStorage := sqluct.NewStorage(sqlx.NewDb(my, "mysql"))
+	myStorage.IdentifierQuoter = sqluct.QuoteBackticks
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"pg": {
+			Storage:       sqluct.NewStorage(sqlx.NewDb(pg, "postgres")),
+			Tables:        map[string]interface{}{"users": new(row)},
+			ResetIdentity: true,
+		},
+		"my": {
+			Storage:       myStorage,
+			Tables:        map[string]interface{}{"users": new(row)},
+			ResetIdentity: true,
+		},
+	}
+
+	pgMock.ExpectExec(`DELETE FROM users`).WillReturnResult(driver.ResultNoRows)
+	pgMock.ExpectExec(`SELECT setval\(seq, 1, false\) FROM \(SELECT pg_get_serial_sequence\(\$1, attname\)`).
+		WithArgs("users").WillReturnResult(driver.ResultNoRows)
+
+	myMock.ExpectExec("DELETE FROM `users`").WillReturnResult(driver.ResultNoRows)
+	myMock.ExpectExec("ALTER TABLE `users` AUTO_INCREMENT = 1").WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/ResetIdentity.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, pgMock.ExpectationsWereMet())
+	assert.NoError(t, myMock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_resetIdentityTransactional(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my": {
+			Storage:       sqluct.NewStorage(sqlx.NewDb(db, "mysql")),
+			Tables:        map[string]interface{}{"users": new(row)},
+			ResetIdentity: true,
+			Transactional: true,
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/ResetIdentityTransactional.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	assert.NotEqual(t, 0, status)
+	assert.Contains(t, buf.String(), "ResetIdentity can not be used with Transactional instance: my")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_snapshot(t *testing.T) {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	type order struct {
+		ID     int `db:"id"`
+		UserID int `db:"user_id"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"users":  new(user),
+				"orders": new(order),
+			},
+			Dependencies: map[string][]string{
+				"orders": {"users"},
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT id, user_id FROM orders`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id"}).AddRow(1, 1))
+	mock.ExpectQuery(`SELECT id, name FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "foo").AddRow(2, "bar"))
+
+	mock.ExpectExec(`DELETE FROM orders`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO users \(id,name\) VALUES \(\$1,\$2\),\(\$3,\$4\)`).
+		WithArgs(1, "foo", 2, "bar").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO orders \(id,user_id\) VALUES \(\$1,\$2\)`).
+		WithArgs(1, 1).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Snapshot.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_snapshotBatches(t *testing.T) {
+	type user struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"users": new(user),
+			},
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for i := 1; i <= 600; i++ {
+		rows.AddRow(i, "foo")
+	}
+
+	mock.ExpectQuery(`SELECT id, name FROM users`).WillReturnRows(rows)
+	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(driver.ResultNoRows)
+	// 999 bind parameters allow 499 rows of 2 columns in a statement.
+	mock.ExpectExec(`INSERT INTO users \(id,name\) VALUES \(\$1,\$2\),.+,\(\$997,\$998\)$`).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO users \(id,name\) VALUES \(\$1,\$2\),.+,\(\$201,\$202\)$`).
+		WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Snapshot.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_fixture(t *testing.T) {
+	type row struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	type order struct {
+		ID     int `db:"id"`
+		UserID int `db:"user_id"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Fixtures = fstest.MapFS{
+		"shop/include.txt":              {Data: []byte("# Common data.\nproducts/basic\n")},
+		"shop/orders.tsv":               {Data: []byte("id\tuser_id\n1\t1\n")},
+		"shop/users.csv":                {Data: []byte("id,name\n1,foo\n")},
+		"products/basic/products.table": {Data: []byte("| id | name |\n| 1  | bar  |\n")},
+	}
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"users":    new(row),
+				"products": new(row),
+				"orders":   new(order),
+			},
+			Dependencies: map[string][]string{
+				"orders": {"users"},
+			},
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO products`).WithArgs(1, "bar").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO users`).WithArgs(1, "foo").WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO orders`).WithArgs(1, 1).WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/Fixture.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_files(t *testing.T) {
+	for name, configure := range map[string]func(dbm *dbdog.Manager){
+		"fs": func(dbm *dbdog.Manager) {
+			dbm.Files = fstest.MapFS{
+				"fixtures/rows.csv": {Data: []byte("id,foo\n1,foo-1\n")},
+			}
+			dbm.FilesDir = "fixtures"
+		},
+		"relativeToFeature": func(dbm *dbdog.Manager) {
+			dbm.FilesRelativeToFeature = true
+		},
+	} {
+		configure := configure
+
+		t.Run(name, func(t *testing.T) {
+			dbm := dbdog.NewManager()
+			configure(dbm)
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			dbm.Instances = map[string]dbdog.Instance{
+				"my_db": {
+					Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+					Tables: map[string]interface{}{
+						"my_table": dbdog.Row{"id": 0, "foo": "", "bar": nil, "created_at": nil, "deleted_at": nil},
+					},
+				},
+			}
+
+			mock.ExpectExec(`INSERT INTO my_table`).WillReturnResult(driver.ResultNoRows)
+
+			buf := bytes.NewBuffer(nil)
+
+			suite := godog.TestSuite{
+				Name: "DatabaseContext",
+				ScenarioInitializer: func(s *godog.ScenarioContext) {
+					dbm.RegisterSteps(s)
+				},
+				Options: &godog.Options{
+					Format: "pretty",
+					Output: buf,
+					Paths:  []string{"_testdata/Files.feature"},
+					Strict: true,
+				},
+			}
+			status := suite.Run()
+
+			if status != 0 {
+				t.Fatal(buf.String())
+			}
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestManager_RegisterSteps_filesTableMalformed(t *testing.T) {
+	for name, tc := range map[string]struct {
+		contents string
+		err      string
+	}{
+		"extraCell":   {contents: "| id | foo |\n| 1 | foo-1 | bar |\n", err: "at line 2: 3 received, 2 expected"},
+		"missingCell": {contents: "| id | foo |\n\n| 1 |\n", err: "at line 3: 1 received, 2 expected"},
+	} {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			dbm := dbdog.NewManager()
+			dbm.Files = fstest.MapFS{
+				"rows.table": {Data: []byte(tc.contents)},
+			}
+
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+
+			dbm.Instances = map[string]dbdog.Instance{
+				"my_db": {
+					Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+					Tables: map[string]interface{}{
+						"my_table": dbdog.Row{"id": 0, "foo": ""},
+					},
+				},
+			}
+
+			buf := bytes.NewBuffer(nil)
+
+			suite := godog.TestSuite{
+				Name: "DatabaseContext",
+				ScenarioInitializer: func(s *godog.ScenarioContext) {
+					dbm.RegisterSteps(s)
+				},
+				Options: &godog.Options{
+					Format: "pretty",
+					Output: buf,
+					Paths:  []string{"_testdata/FilesTable.feature"},
+					Strict: true,
+				},
+			}
+			status := suite.Run()
+
+			assert.NotEqual(t, 0, status)
+			assert.Contains(t, buf.String(), "unexpected number of cells in gherkin table row "+tc.err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+type jsonMeta struct {
+	Tags []string `json:"tags"`
+}
+
+func (m *jsonMeta) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(m)
+}
+
+func (m *jsonMeta) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return errors.New("unexpected type")
+	}
+
+	return json.Unmarshal(b, m)
+}
+
+func TestManager_RegisterSteps_json(t *testing.T) {
+	type row struct {
+		ID   int       `db:"id"`
+		Name *string   `db:"name"`
+		Meta *jsonMeta `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.TableMapper.Decoder.RegisterFunc(func(s string) (interface{}, error) {
+		m := jsonMeta{}
+		err := json.Unmarshal([]byte(s), &m)
+
+		return m, err
+	}, jsonMeta{})
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,name,meta\) VALUES .+`).
+		WithArgs(1, "foo", []byte(`{"tags":["a","b"]}`), 2, nil, nil).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectQuery(`SELECT id, name, meta FROM my_table WHERE id = \$1 AND name = \$2`).
+		WithArgs(1, "foo").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "meta"}).AddRow(1, "foo", []byte(`{"tags":["a","b"]}`)))
+
+	mock.ExpectQuery(`SELECT id, name, meta FROM my_table WHERE id = \$1 AND name IS NULL AND meta IS NULL`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "meta"}).AddRow(2, nil, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/JSON.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_yaml(t *testing.T) {
+	type user struct {
+		ID   int       `db:"id"`
+		Name *string   `db:"name"`
+		Meta *jsonMeta `db:"meta"`
+	}
+
+	type order struct {
+		ID     int `db:"id"`
+		UserID int `db:"user_id"`
+	}
+
+	type product struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.TableMapper.Decoder.RegisterFunc(func(s string) (interface{}, error) {
+		m := jsonMeta{}
+		err := json.Unmarshal([]byte(s), &m)
+
+		return m, err
+	}, jsonMeta{})
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	anotherDB, anotherMock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"users":  new(user),
+				"orders": new(order),
+			},
+			Dependencies: map[string][]string{
+				"orders": {"users"},
+			},
+		},
+		"my_another_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(anotherDB, "sqlmock")),
+			Tables: map[string]interface{}{
+				"products": new(product),
+			},
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO users \(id,name,meta\) VALUES .+`).
+		WithArgs(1, "foo", []byte(`{"tags":["a","b"]}`), 2, nil, nil).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO orders \(id,user_id\) VALUES .+`).
+		WithArgs(1, 1).
+		WillReturnResult(driver.ResultNoRows)
+	anotherMock.ExpectExec(`INSERT INTO products \(id,name\) VALUES .+`).
+		WithArgs(1, "bar").
+		WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/YAML.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, anotherMock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_jsonDocString(t *testing.T) {
+	type row struct {
+		ID   int       `db:"id"`
+		Name *string   `db:"name"`
+		Meta *jsonMeta `db:"meta"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.TableMapper.Decoder.RegisterFunc(func(s string) (interface{}, error) {
+		m := jsonMeta{}
+		err := json.Unmarshal([]byte(s), &m)
+
+		return m, err
+	}, jsonMeta{})
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,name,meta\) VALUES .+`).
+		WithArgs(1, "foo", []byte(`{"tags":["a","b"]}`), 2, nil, nil).
+		WillReturnResult(driver.ResultNoRows)
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+
+	mock.ExpectQuery(`SELECT id, name, meta FROM my_table WHERE name = \$1`).
+		WithArgs("foo").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "meta"}).AddRow(1, "foo", []byte(`{"tags":["a","b"]}`)))
+
+	mock.ExpectQuery(`SELECT id, name, meta FROM my_table WHERE id = \$1 AND name IS NULL AND meta IS NULL`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "meta"}).AddRow(2, nil, nil))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/JSONDocString.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, 1, dbm.Vars.GetAll()["$id1"])
+}
+
+func TestManager_RegisterSteps_jsonDocStringEmpty(t *testing.T) {
+	type row struct {
+		ID   int    `db:"id"`
+		Name string `db:"name"`
+	}
+
+	dbm := dbdog.NewManager()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/JSONDocStringEmpty.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_csvDialect(t *testing.T) {
+	type row struct {
+		ID  int     `db:"id"`
+		Foo *string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.CSV = dbdog.CSVDialect{
+		Comma:            ';',
+		Comment:          '#',
+		TrimLeadingSpace: true,
+		Null:             `\N`,
+	}
+	dbm.Files = fstest.MapFS{
+		"rows.csv": {Data: []byte("# Exported rows.\nid; foo\n1; foo-1\n2; \\N\n")},
+		"rows.tsv": {Data: []byte("id\tfoo\n3\tfoo;3\n")},
+	}
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES .+`).
+		WithArgs(1, "foo-1", 2, nil).
+		WillReturnResult(driver.ResultNoRows)
+	mock.ExpectExec(`INSERT INTO my_table \(id,foo\) VALUES .+`).
+		WithArgs(3, "foo;3").
+		WillReturnResult(driver.ResultNoRows)
+
+	buf := bytes.NewBuffer(nil)
+
+	suite := godog.TestSuite{
+		Name: "DatabaseContext",
+		ScenarioInitializer: func(s *godog.ScenarioContext) {
+			dbm.RegisterSteps(s)
+		},
+		Options: &godog.Options{
+			Format: "pretty",
+			Output: buf,
+			Paths:  []string{"_testdata/CSVDialect.feature"},
+			Strict: true,
+		},
+	}
+	status := suite.Run()
+
+	if status != 0 {
+		t.Fatal(buf.String())
+	}
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_golden(t *testing.T) {
+	type row struct {
+		ID  int     `db:"id"`
+		Foo *string `db:"foo"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.FilesDir = t.TempDir()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+			OrderBy: map[string]string{
+				"my_table": "id",
+			},
+		},
+	}
+
+	run := func() int {
+		buf := bytes.NewBuffer(nil)
+
+		suite := godog.TestSuite{
+			Name: "DatabaseContext",
+			ScenarioInitializer: func(s *godog.ScenarioContext) {
+				dbm.RegisterSteps(s)
+			},
+			Options: &godog.Options{
+				Format: "pretty",
+				Output: buf,
+				Paths:  []string{"_testdata/Golden.feature"},
+				Strict: true,
+			},
+		}
+
+		return suite.Run()
+	}
+
+	// Golden files are updated.
+	dbm.UpdateGolden = true
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT id, foo FROM my_table ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "a|b").AddRow(2, nil))
+	}
+
+	assert.Equal(t, 0, run())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	csvContents, err := os.ReadFile(filepath.Join(dbm.FilesDir, "my_table.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "id,foo\n1,a|b\n2,NULL\n", string(csvContents))
+
+	tableContents, err := os.ReadFile(filepath.Join(dbm.FilesDir, "my_table.table"))
+	assert.NoError(t, err)
+	assert.Equal(t, "| id | foo  |\n| 1  | a\\|b |\n| 2  | NULL |\n", string(tableContents))
+
+	// Golden files are asserted.
+	dbm.UpdateGolden = false
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(2))
+		mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo = \$2`).
+			WithArgs(1, "a|b").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(1, "a|b"))
+		mock.ExpectQuery(`SELECT id, foo FROM my_table WHERE id = \$1 AND foo IS NULL`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}).AddRow(2, nil))
+	}
+
+	assert.Equal(t, 0, run())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Golden files of an empty table have only header.
+	dbm.UpdateGolden = true
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT id, foo FROM my_table ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "foo"}))
+	}
+
+	assert.Equal(t, 0, run())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	dbm.UpdateGolden = false
+
+	for i := 0; i < 2; i++ {
+		mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+	}
+
+	assert.Equal(t, 0, run())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Golden files can not be updated in Manager.Files.
+	dbm.UpdateGolden = true
+	dbm.Files = os.DirFS(dbm.FilesDir)
+	dbm.FilesDir = ""
+
+	assert.NotEqual(t, 0, run())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestManager_RegisterSteps_goldenTime(t *testing.T) {
+	type row struct {
+		ID int       `db:"id"`
+		At time.Time `db:"at"`
+	}
+
+	dbm := dbdog.NewManager()
+	dbm.FilesDir = t.TempDir()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	dbm.Instances = map[string]dbdog.Instance{
+		"my_db": {
+			Storage: sqluct.NewStorage(sqlx.NewDb(db, "sqlmock")),
+			Tables: map[string]interface{}{
+				"my_table": new(row),
+			},
+		},
+	}
+
+	run := func() {
+		buf := bytes.NewBuffer(nil)
+
+		suite := godog.TestSuite{
+			Name: "DatabaseContext",
+			ScenarioInitializer: func(s *godog.ScenarioContext) {
+				dbm.RegisterSteps(s)
+			},
+			Options: &godog.Options{
+				Format: "pretty",
+				Output: buf,
+				Paths:  []string{"_testdata/GoldenTime.feature"},
+				Strict: true,
+			},
+		}
+		status := suite.Run()
+
+		if status != 0 {
+			t.Fatal(buf.String())
+		}
+	}
+
+	at := mustParseTime("2021-01-01T00:00:00.123456Z")
+
+	// Golden file is dumped.
+	dbm.UpdateGolden = true
+
+	mock.ExpectQuery(`SELECT id, at FROM my_table`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at"}).AddRow(1, at))
+
+	run()
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	contents, err := os.ReadFile(filepath.Join(dbm.FilesDir, "my_table.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "id,at\n1,2021-01-01T00:00:00.123456Z\n", string(contents))
+
+	// Dumped golden file is asserted.
+	dbm.UpdateGolden = false
+
+	mock.ExpectQuery(`SELECT COUNT\(1\) AS c FROM my_table`).WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, at FROM my_table WHERE id = \$1 AND at = \$2`).
+		WithArgs(1, at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "at"}).AddRow(1, at))
+
+	run()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,110 @@
+package dbdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/bool64/sqluct"
+)
+
+var (
+	errMissingOrder  = errors.New("missing ORDER BY for table")
+	errMissingRow    = errors.New("missing row")
+	errUnexpectedRow = errors.New("unexpected row")
+)
+
+// assertOrderedRows compares gherkin table with table rows in order defined by orderBy or Instance.OrderBy.
+//
+// Gherkin rows are compared with the first rows of ordered table, without exhaustiveList
+// further rows are not checked.
+func (m *Manager) assertOrderedRows(
+	ctx context.Context, tableName, dbName, orderBy string, data [][]string, exhaustiveList bool,
+) (err error) {
+	t, err := m.makeTableQuery(ctx, tableName, dbName, data)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose table contents to simplify test debugging.
+		if err != nil {
+			err = t.exposeContents(err)
+		}
+	}()
+
+	if orderBy != "" {
+		t.orderBy = orderBy
+	}
+
+	if t.orderBy == "" {
+		return fmt.Errorf("%w %s in database %s", errMissingOrder, tableName, dbName)
+	}
+
+	if exhaustiveList {
+		if err = t.checkCount(); err != nil {
+			return err
+		}
+	}
+
+	qb := t.storage.QueryBuilder().
+		Select(t.colNames...).
+		From(t.table).
+		OrderBy(t.orderBy).
+		Limit(uint64(len(data) - 1))
+
+	received := reflect.New(reflect.SliceOf(reflect.TypeOf(t.row).Elem()))
+
+	if err = t.storage.Select(t.ctx, qb, received.Interface()); err != nil {
+		return fmt.Errorf("failed to query rows: %w", err)
+	}
+
+	var onSetErr error
+
+	replaces, err := t.makeReplaces(&onSetErr)
+	if err != nil {
+		return err
+	}
+
+	colOption := sqluct.Columns(t.colNames...)
+
+	err = t.mapper.IterateTable(IterateConfig{
+		Data:       data,
+		Item:       t.row,
+		SkipDecode: t.skipDecode,
+		Replaces:   replaces,
+		ReceiveRow: func(index int, row interface{}, _ []string, rawValues []string) error {
+			checkCols := t.postCheck
+
+			for _, c := range t.rowConditions(row) {
+				for col := range c {
+					checkCols = append(checkCols, col)
+				}
+			}
+
+			t.postCheck = t.postCheck[:0]
+
+			if index >= received.Elem().Len() {
+				return fmt.Errorf("%w at position %d (%+v)", errMissingRow, index, row)
+			}
+
+			err := t.doPostCheck(t.colNames, checkCols,
+				combine(t.storage.Mapper.ColumnsValues(reflect.ValueOf(row), colOption)),
+				combine(t.storage.Mapper.ColumnsValues(received.Elem().Index(index).Addr(), colOption)),
+				rawValues)
+			if err != nil {
+				// Cause is wrapped to keep error chain, Go 1.16 does not support multiple %w verbs.
+				return fmt.Errorf("%s at position %d (%+v): %w", errUnexpectedRow, index, row, err)
+			}
+
+			return nil
+		},
+	})
+
+	if err == nil && onSetErr != nil {
+		err = onSetErr
+	}
+
+	return err
+}
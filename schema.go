@@ -0,0 +1,285 @@
+package dbdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bool64/sqluct"
+)
+
+// introspectedTables is a cache of row structures built from table schema, it is shared between scenarios.
+type introspectedTables struct {
+	mu    sync.Mutex
+	items map[string]interface{}
+}
+
+func (t *introspectedTables) set(dbName, tableName string, row interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.items == nil {
+		t.items = make(map[string]interface{})
+	}
+
+	t.items[dbName+"/"+tableName] = row
+}
+
+func (t *introspectedTables) get(dbName, tableName string) (interface{}, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	row, ok := t.items[dbName+"/"+tableName]
+
+	return row, ok
+}
+
+// instanceTable returns database instance and row structure of a table.
+//
+// If table is not registered in Instance.Tables and Instance.Introspect is enabled,
+// row structure is built from table schema and cached.
+func (m *Manager) instanceTable(ctx context.Context, tableName, dbName string) (Instance, interface{}, error) {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return instance, nil, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	if row, ok := instance.Tables[tableName]; ok {
+		return instance, rowItem(row), nil
+	}
+
+	if !instance.Introspect {
+		return instance, nil, fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+	}
+
+	if row, ok := m.introspected.get(dbName, tableName); ok {
+		return instance, row, nil
+	}
+
+	row, err := introspectRow(instanceCtx(ctx, dbName), instance.Storage, tableName)
+	if err != nil {
+		return instance, nil, fmt.Errorf("failed to introspect table %s in database %s: %w", tableName, dbName, err)
+	}
+
+	if row == nil {
+		return instance, nil, fmt.Errorf("%w %s in database %s", errUnknownTable, tableName, dbName)
+	}
+
+	m.introspected.set(dbName, tableName, row)
+
+	return instance, row, nil
+}
+
+// tableColumn describes column of table schema.
+type tableColumn struct {
+	Name string `db:"name"`
+	Type string `db:"type"`
+}
+
+// introspectRow builds a row structure from table schema, it returns nil if table has no columns.
+func introspectRow(ctx context.Context, storage *sqluct.Storage, tableName string) (interface{}, error) {
+	var columns []tableColumn
+
+	if err := storage.Select(ctx, columnsQuery(storage, tableName), &columns); err != nil {
+		return nil, err
+	}
+
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(columns))
+	types := make([]reflect.Type, 0, len(columns))
+
+	for _, c := range columns {
+		names = append(names, c.Name)
+		types = append(types, columnType(c.Type))
+	}
+
+	return rowStruct(names, types), nil
+}
+
+// columnsQuery makes a query of table columns according to database driver.
+func columnsQuery(storage *sqluct.Storage, tableName string) sqluct.ToSQL {
+	driver := storage.DB().DriverName()
+
+	if strings.HasPrefix(driver, "sqlite") {
+		return sqlStatement{
+			query: "SELECT name, type FROM pragma_table_info(?) ORDER BY cid",
+			args:  []interface{}{tableName},
+		}
+	}
+
+	qb := storage.QueryBuilder().
+		Select("column_name AS name", "data_type AS type").
+		From("information_schema.columns").
+		OrderBy("ordinal_position")
+
+	schema, name, qualified := splitSchema(tableName)
+
+	qb = qb.Where("table_name = ?", name)
+
+	switch {
+	case qualified:
+		qb = qb.Where("table_schema = ?", schema)
+	case strings.HasPrefix(driver, "mysql"):
+		qb = qb.Where("table_schema = DATABASE()")
+	case strings.HasPrefix(driver, "postgres") || strings.HasPrefix(driver, "pgx"):
+		qb = qb.Where("table_schema = current_schema()")
+	}
+
+	return qb
+}
+
+// splitSchema splits schema-qualified table name, e.g. "public.users".
+func splitSchema(tableName string) (schema, name string, ok bool) {
+	if pos := strings.Index(tableName, "."); pos != -1 {
+		return tableName[:pos], tableName[pos+1:], true
+	}
+
+	return "", tableName, false
+}
+
+// columnType maps SQL type to a nullable Go type.
+func columnType(sqlType string) reflect.Type {
+	t := strings.ToLower(strings.TrimSpace(sqlType))
+
+	// Size and modifiers are ignored, e.g. VARCHAR(255), INT(11) UNSIGNED, TIMESTAMP(6) WITH TIME ZONE.
+	if pos := strings.Index(t, "("); pos != -1 {
+		if end := strings.Index(t[pos:], ")"); end != -1 {
+			t = t[:pos] + t[pos+end+1:]
+		}
+	}
+
+	t = strings.Join(strings.Fields(strings.TrimSuffix(strings.TrimSpace(t), " unsigned")), " ")
+
+	switch t {
+	case "integer", "int", "int2", "int4", "int8", "bigint", "smallint", "tinyint", "mediumint",
+		"serial", "serial2", "serial4", "serial8", "bigserial", "smallserial":
+		return reflect.TypeOf(new(int64))
+	case "boolean", "bool":
+		return reflect.TypeOf(new(bool))
+	case "real", "float", "float4", "float8", "double", "double precision":
+		return reflect.TypeOf(new(float64))
+	case "date", "datetime", "timestamp", "timestamptz", "timestamp with time zone", "timestamp without time zone",
+		"time", "timetz", "time with time zone", "time without time zone":
+		return reflect.TypeOf(new(time.Time))
+	default:
+		return reflect.TypeOf(new(string))
+	}
+}
+
+// referencedTables returns names of tables that are referenced by foreign keys of a table.
+func referencedTables(ctx context.Context, storage *sqluct.Storage, tableName string) ([]string, error) {
+	var (
+		tables []string
+		driver = storage.DB().DriverName()
+		query  sqluct.ToSQL
+	)
+
+	switch {
+	case strings.HasPrefix(driver, "sqlite"):
+		query = sqlStatement{
+			query: `SELECT DISTINCT "table" FROM pragma_foreign_key_list(?)`,
+			args:  []interface{}{tableName},
+		}
+	case strings.HasPrefix(driver, "mysql"):
+		qb := storage.QueryBuilder().Select().
+			From("information_schema.key_column_usage").
+			Where("referenced_table_name IS NOT NULL")
+
+		// Names of referenced tables are qualified with schema if table name is qualified.
+		if schema, name, ok := splitSchema(tableName); ok {
+			qb = qb.Columns("DISTINCT CONCAT(referenced_table_schema, '.', referenced_table_name)").
+				Where("table_schema = ?", schema).
+				Where("table_name = ?", name)
+		} else {
+			qb = qb.Columns("DISTINCT referenced_table_name").
+				Where("table_schema = DATABASE()").
+				Where("table_name = ?", tableName)
+		}
+
+		query = qb
+	default:
+		qb := storage.QueryBuilder().Select().
+			From("information_schema.table_constraints tc").
+			Join("information_schema.constraint_column_usage ccu " +
+				"ON tc.constraint_name = ccu.constraint_name AND tc.constraint_schema = ccu.constraint_schema").
+			Where("tc.constraint_type = 'FOREIGN KEY'")
+
+		if schema, name, ok := splitSchema(tableName); ok {
+			qb = qb.Columns("DISTINCT ccu.table_schema || '.' || ccu.table_name").
+				Where("tc.table_schema = ?", schema).
+				Where("tc.table_name = ?", name)
+		} else {
+			qb = qb.Columns("DISTINCT ccu.table_name").
+				Where("tc.table_schema = current_schema()").
+				Where("tc.table_name = ?", tableName)
+		}
+
+		query = qb
+	}
+
+	if err := storage.Select(ctx, query, &tables); err != nil {
+		return nil, err
+	}
+
+	return tables, nil
+}
+
+var (
+	errUnsupportedDriver          = errors.New("unsupported database driver")
+	errTransactionalResetIdentity = errors.New("ResetIdentity can not be used with Transactional instance")
+)
+
+// resetIdentity restarts auto increment sequences of an empty table according to database driver.
+//
+// Postgres sequences are reset with setval instead of TRUNCATE ... RESTART IDENTITY,
+// because TRUNCATE fails for tables that are referenced by foreign keys.
+func resetIdentity(ctx context.Context, storage *sqluct.Storage, tableName string) error {
+	driver := storage.DB().DriverName()
+
+	var query sqluct.ToSQL
+
+	quotedName := tableName
+	if storage.IdentifierQuoter != nil {
+		quotedName = storage.IdentifierQuoter(tableName)
+	}
+
+	switch {
+	case strings.HasPrefix(driver, "postgres") || strings.HasPrefix(driver, "pgx"):
+		query = sqlStatement{
+			query: "SELECT setval(seq, 1, false) FROM (" +
+				"SELECT pg_get_serial_sequence($1, attname) AS seq FROM pg_attribute " +
+				"WHERE attrelid = $1::regclass AND attnum > 0 AND NOT attisdropped" +
+				") s WHERE seq IS NOT NULL",
+			args: []interface{}{quotedName},
+		}
+	case strings.HasPrefix(driver, "mysql"):
+		query = sqluct.StringStatement("ALTER TABLE " + quotedName + " AUTO_INCREMENT = 1")
+	case strings.HasPrefix(driver, "sqlite"):
+		// Table sqlite_sequence only exists if there are tables with AUTOINCREMENT.
+		var cnt int
+
+		err := storage.Select(ctx, sqluct.StringStatement(
+			"SELECT COUNT(1) FROM sqlite_master WHERE type = 'table' AND name = 'sqlite_sequence'"), &cnt)
+		if err != nil || cnt == 0 {
+			return err
+		}
+
+		query = sqlStatement{
+			query: "DELETE FROM sqlite_sequence WHERE name = ?",
+			args:  []interface{}{tableName},
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnsupportedDriver, driver)
+	}
+
+	_, err := storage.Exec(ctx, query)
+
+	return err
+}
@@ -0,0 +1,191 @@
+package dbdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/cucumber/godog"
+)
+
+var errUnknownSnapshot = errors.New("unknown snapshot")
+
+// snapshotMaxParams limits number of bind parameters of a single restore statement,
+// the lowest limit among supported databases is 999 of older SQLite versions.
+const snapshotMaxParams = 999
+
+// tableSnapshot keeps rows of a table as a slice of row structures.
+type tableSnapshot struct {
+	tableName string
+	rows      reflect.Value
+}
+
+// snapshots is an in-memory storage of database snapshots, it is shared between scenarios.
+type snapshots struct {
+	mu    sync.Mutex
+	items map[string][]tableSnapshot
+}
+
+func (s *snapshots) set(dbName, name string, tables []tableSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.items == nil {
+		s.items = make(map[string][]tableSnapshot)
+	}
+
+	s.items[dbName+"/"+name] = tables
+}
+
+func (s *snapshots) get(dbName, name string) ([]tableSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tables, ok := s.items[dbName+"/"+name]
+
+	return tables, ok
+}
+
+func (m *Manager) registerSnapshots(s *godog.ScenarioContext) {
+	s.Step(`database "([^"]*)" snapshot "([^"]*)" is taken$`,
+		m.databaseSnapshotIsTaken)
+
+	s.Step(`database "([^"]*)" is restored to snapshot "([^"]*)"$`,
+		m.databaseIsRestoredToSnapshot)
+
+	s.Step(`database snapshot "([^"]*)" is taken$`,
+		func(ctx context.Context, name string) error {
+			return m.databaseSnapshotIsTaken(ctx, DefaultDatabase, name)
+		})
+
+	s.Step(`database is restored to snapshot "([^"]*)"$`,
+		func(ctx context.Context, name string) error {
+			return m.databaseIsRestoredToSnapshot(ctx, DefaultDatabase, name)
+		})
+}
+
+// databaseSnapshotIsTaken reads contents of all registered tables of an instance into a named snapshot.
+func (m *Manager) databaseSnapshotIsTaken(ctx context.Context, dbName, name string) error {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	tableNames := make([]string, 0, len(instance.Tables))
+
+	for tableName := range instance.Tables {
+		tableNames = append(tableNames, tableName)
+	}
+
+	sort.Strings(tableNames)
+
+	ctx = instanceCtx(ctx, dbName)
+	tables := make([]tableSnapshot, 0, len(tableNames))
+
+	for _, tableName := range tableNames {
+		row := rowItem(instance.Tables[tableName])
+
+		itemType, err := itemType(row)
+		if err != nil {
+			return err
+		}
+
+		rows := reflect.New(reflect.SliceOf(itemType))
+
+		err = instance.Storage.Select(ctx, instance.Storage.SelectStmt(tableName, row), rows.Interface())
+		if err != nil {
+			return fmt.Errorf("failed to read table %s in database %s: %w", tableName, dbName, err)
+		}
+
+		tables = append(tables, tableSnapshot{tableName: tableName, rows: rows.Elem()})
+	}
+
+	m.snapshots.set(dbName, name, tables)
+
+	return nil
+}
+
+// databaseIsRestoredToSnapshot replaces contents of snapshot tables with snapshot rows.
+//
+// Rows are deleted in order of table dependencies and inserted in reversed order, PostCleanup statements
+// and ResetIdentity are not applied to keep sequences consistent with restored rows.
+func (m *Manager) databaseIsRestoredToSnapshot(ctx context.Context, dbName, name string) error {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	tables, ok := m.snapshots.get(dbName, name)
+	if !ok {
+		return fmt.Errorf("%w %s of database %s", errUnknownSnapshot, name, dbName)
+	}
+
+	tableNames := make([]string, 0, len(tables))
+	tableRows := make(map[string]reflect.Value, len(tables))
+
+	for _, t := range tables {
+		tableNames = append(tableNames, t.tableName)
+		tableRows[t.tableName] = t.rows
+	}
+
+	order, err := m.deletionOrder(ctx, tableNames, dbName)
+	if err != nil {
+		return err
+	}
+
+	ctx = instanceCtx(ctx, dbName)
+
+	for _, tableName := range order {
+		if _, err := instance.Storage.Exec(ctx, instance.Storage.DeleteStmt(tableName)); err != nil {
+			return fmt.Errorf("failed to delete from table %s in db %s: %w", tableName, dbName, err)
+		}
+	}
+
+	for i := len(order) - 1; i >= 0; i-- {
+		tableName := order[i]
+		rows := tableRows[tableName]
+
+		if rows.Len() == 0 {
+			continue
+		}
+
+		trackTable(ctx, dbName, tableName)
+
+		if err := restoreRows(ctx, instance, tableName, rows); err != nil {
+			return fmt.Errorf("failed to restore table %s in db %s: %w", tableName, dbName, err)
+		}
+	}
+
+	return nil
+}
+
+// restoreRows inserts rows in batches to keep number of bind parameters within snapshotMaxParams.
+func restoreRows(ctx context.Context, instance Instance, tableName string, rows reflect.Value) error {
+	_, args, err := instance.Storage.InsertStmt(tableName, rows.Index(0).Interface()).ToSql()
+	if err != nil {
+		return err
+	}
+
+	batch := 1
+	if len(args) > 0 && len(args) < snapshotMaxParams {
+		batch = snapshotMaxParams / len(args)
+	}
+
+	for i := 0; i < rows.Len(); i += batch {
+		j := i + batch
+		if j > rows.Len() {
+			j = rows.Len()
+		}
+
+		qb := instance.Storage.InsertStmt(tableName, rows.Slice(i, j).Interface())
+
+		if _, err := instance.Storage.Exec(ctx, qb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,540 @@
+package dbdog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/cucumber/godog"
+)
+
+var (
+	errUndefinedVariable           = errors.New("undefined variable")
+	errInvalidNumberOfAffectedRows = errors.New("invalid number of affected rows")
+	errMissingSQLQuery             = errors.New("missing SQL query, use 'SQL query is' step to define it")
+	errUnknownColumn               = errors.New("unknown column")
+	errUnexpectedValue             = errors.New("unexpected value")
+	errNotVariable                 = errors.New("not a variable")
+	errInvalidNumberOfColumns      = errors.New("invalid number of columns")
+)
+
+func (m *Manager) registerSQL(s *godog.ScenarioContext) {
+	s.Step(`I execute SQL in database "([^"]*)"(?: expecting (\d+) affected)?[:]?$`,
+		func(ctx context.Context, database, expected string, query *godog.DocString) error {
+			return m.iExecuteSQLInDatabase(ctx, database, expected, query.Content)
+		})
+
+	s.Step(`I execute SQL(?: expecting (\d+) affected)?[:]?$`,
+		func(ctx context.Context, expected string, query *godog.DocString) error {
+			return m.iExecuteSQLInDatabase(ctx, DefaultDatabase, expected, query.Content)
+		})
+
+	s.Step(`SQL query in database "([^"]*)" is[:]?$`,
+		func(ctx context.Context, database string, query *godog.DocString) (context.Context, error) {
+			return m.sqlQueryInDatabaseIs(ctx, database, query.Content)
+		})
+
+	s.Step(`SQL query is[:]?$`,
+		func(ctx context.Context, query *godog.DocString) (context.Context, error) {
+			return m.sqlQueryInDatabaseIs(ctx, DefaultDatabase, query.Content)
+		})
+
+	s.Step(`I store SQL query result in database "([^"]*)" into variables ([^:]+?)[:]?$`,
+		func(ctx context.Context, database, names string, query *godog.DocString) error {
+			return m.iStoreSQLQueryResultInDatabaseIntoVariables(ctx, database, names, query.Content)
+		})
+
+	s.Step(`I store SQL query result into variables ([^:]+?)[:]?$`,
+		func(ctx context.Context, names string, query *godog.DocString) error {
+			return m.iStoreSQLQueryResultInDatabaseIntoVariables(ctx, DefaultDatabase, names, query.Content)
+		})
+
+	s.Step(`I store the result of SQL query in database "([^"]*)" into variables[:]?$`,
+		func(ctx context.Context, database string, data *godog.Table) error {
+			return m.iStoreTheResultOfSQLQueryInDatabaseIntoVariables(ctx, database, Rows(data))
+		})
+
+	s.Step(`I store the result of SQL query into variables[:]?$`,
+		func(ctx context.Context, data *godog.Table) error {
+			return m.iStoreTheResultOfSQLQueryInDatabaseIntoVariables(ctx, DefaultDatabase, Rows(data))
+		})
+
+	s.Step(`the result of SQL query in database "([^"]*)" matches[:]?$`,
+		func(ctx context.Context, database string, data *godog.Table) error {
+			return m.theResultOfSQLQueryInDatabaseMatches(ctx, database, Rows(data))
+		})
+
+	s.Step(`the result of SQL query matches[:]?$`,
+		func(ctx context.Context, data *godog.Table) error {
+			return m.theResultOfSQLQueryInDatabaseMatches(ctx, DefaultDatabase, Rows(data))
+		})
+}
+
+// sqlStatement is a raw SQL statement with arguments.
+type sqlStatement struct {
+	query string
+	args  []interface{}
+}
+
+// ToSql implements sqluct.ToSQL.
+func (s sqlStatement) ToSql() (string, []interface{}, error) { // nolint // Method name matches ext. implementation.
+	return s.query, s.args, nil
+}
+
+var (
+	// varPatterns caches patterns of variable names per variable prefix.
+	varPatterns sync.Map
+
+	// dollarQuote matches opening tag of Postgres dollar-quoted string, e.g. $$ or $body$.
+	dollarQuote = regexp.MustCompile(`^\$([a-zA-Z_]\w*)?\$`)
+)
+
+// varPattern returns a pattern of variable name anchored at the beginning of string.
+func varPattern(prefix string) *regexp.Regexp {
+	if p, ok := varPatterns.Load(prefix); ok {
+		return p.(*regexp.Regexp)
+	}
+
+	p := regexp.MustCompile(`^` + regexp.QuoteMeta(prefix) + `[a-zA-Z_]\w*`)
+	varPatterns.Store(prefix, p)
+
+	return p
+}
+
+// bindVars replaces variables in SQL query with placeholders and collects their values as arguments.
+//
+// Quoted strings ('...'), quoted identifiers ("...") and dollar-quoted strings ($tag$...$tag$) are kept intact.
+func (m *Manager) bindVars(query string, format squirrel.PlaceholderFormat) (sqlStatement, error) {
+	if format == nil {
+		format = squirrel.Dollar
+	}
+
+	// Literal question marks are escaped to survive placeholder replacement.
+	if format != squirrel.Question {
+		query = strings.ReplaceAll(query, "?", "??")
+	}
+
+	prefix := m.Vars.VarPrefix
+	if prefix == "" {
+		prefix = "$"
+	}
+
+	var (
+		args    []interface{}
+		res     strings.Builder
+		pattern = varPattern(prefix)
+	)
+
+	for i := 0; i < len(query); {
+		if n := quotedLen(query[i:]); n > 0 {
+			res.WriteString(query[i : i+n])
+			i += n
+
+			continue
+		}
+
+		name := pattern.FindString(query[i:])
+		if name == "" {
+			res.WriteByte(query[i])
+			i++
+
+			continue
+		}
+
+		val, found := m.Vars.Get(name)
+		if !found {
+			return sqlStatement{}, fmt.Errorf("%w %s", errUndefinedVariable, name)
+		}
+
+		args = append(args, val)
+
+		res.WriteString("?")
+		i += len(name)
+	}
+
+	query, err := format.ReplacePlaceholders(res.String())
+	if err != nil {
+		return sqlStatement{}, err
+	}
+
+	return sqlStatement{query: query, args: args}, nil
+}
+
+// quotedLen returns length of quoted section at the beginning of query or 0 if there is none,
+// unterminated section spans to the end of query.
+func quotedLen(query string) int {
+	switch query[0] {
+	case '\'', '"':
+		// Doubled quote is an escaped quote and is handled as two adjacent sections.
+		if end := strings.IndexByte(query[1:], query[0]); end != -1 {
+			return end + 2
+		}
+
+		return len(query)
+	case '$':
+		tag := dollarQuote.FindString(query)
+		if tag == "" {
+			return 0
+		}
+
+		if end := strings.Index(query[len(tag):], tag); end != -1 {
+			return len(tag) + end + len(tag)
+		}
+
+		return len(query)
+	default:
+		return 0
+	}
+}
+
+func (m *Manager) iExecuteSQLInDatabase(ctx context.Context, dbName, expected, query string) error {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	stmt, err := m.bindVars(query, instance.Storage.Format)
+	if err != nil {
+		return err
+	}
+
+	res, err := instance.Storage.Exec(instanceCtx(ctx, dbName), stmt)
+	if err != nil {
+		return fmt.Errorf("failed to execute SQL %q, %v in db %s: %w", stmt.query, stmt.args, dbName, err)
+	}
+
+	if expected == "" {
+		return nil
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get number of affected rows: %w", err)
+	}
+
+	want, err := strconv.ParseInt(expected, 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse expected number of affected rows: %w", err)
+	}
+
+	if affected != want {
+		return fmt.Errorf("%w: %d expected, %d affected", errInvalidNumberOfAffectedRows, want, affected)
+	}
+
+	return nil
+}
+
+type sqlQueriesCtxKey struct{}
+
+// sqlQueryInDatabaseIs adds SQL query of database to context for the following result assertion.
+func (m *Manager) sqlQueryInDatabaseIs(ctx context.Context, dbName, query string) (context.Context, error) {
+	if _, ok := m.Instances[dbName]; !ok {
+		return ctx, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	prev, _ := ctx.Value(sqlQueriesCtxKey{}).(map[string]string)
+	queries := make(map[string]string, len(prev)+1)
+
+	for k, v := range prev {
+		queries[k] = v
+	}
+
+	queries[dbName] = query
+
+	return context.WithValue(ctx, sqlQueriesCtxKey{}, queries), nil
+}
+
+// querySQL runs SQL query of database from context and returns column names and scanned rows.
+func (m *Manager) querySQL(ctx context.Context, dbName string) (cols []string, values [][]interface{}, err error) {
+	instance, ok := m.Instances[dbName]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %s", errUnknownDatabase, dbName)
+	}
+
+	queries, _ := ctx.Value(sqlQueriesCtxKey{}).(map[string]string)
+
+	query, ok := queries[dbName]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w in database %s", errMissingSQLQuery, dbName)
+	}
+
+	stmt, err := m.bindVars(query, instance.Storage.Format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := instance.Storage.Query(instanceCtx(ctx, dbName), stmt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query SQL %q, %v in db %s: %w", stmt.query, stmt.args, dbName, err)
+	}
+
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	cols, err = rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for rows.Next() {
+		row, err := scanRow(rows, len(cols))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		values = append(values, row)
+	}
+
+	return cols, values, rows.Err()
+}
+
+// theResultOfSQLQueryInDatabaseMatches compares rows of SQL query result with gherkin table in order.
+func (m *Manager) theResultOfSQLQueryInDatabaseMatches(ctx context.Context, dbName string, data [][]string) (err error) {
+	m.checkInit()
+
+	cols, values, err := m.querySQL(ctx, dbName)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose query result to simplify test debugging.
+		if err != nil {
+			err = m.exposeResult(err, cols, values)
+		}
+	}()
+
+	if len(data) == 0 {
+		return errRowRequired
+	}
+
+	colIdx := make([]int, len(data[0]))
+
+	for i, name := range data[0] {
+		colIdx[i] = -1
+
+		for j, col := range cols {
+			if col == name {
+				colIdx[i] = j
+			}
+		}
+
+		if colIdx[i] == -1 {
+			return fmt.Errorf("%w %s in SQL query result", errUnknownColumn, name)
+		}
+	}
+
+	if len(data)-1 != len(values) {
+		return fmt.Errorf("%w: %d expected, %d found", errInvalidNumberOfRows, len(data)-1, len(values))
+	}
+
+	for i, row := range data[1:] {
+		for j, cell := range row {
+			if err := m.matchCell(cell, values[i][colIdx[j]]); err != nil {
+				return fmt.Errorf("row %d, column %s: %w", i, data[0][j], err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// matchCell checks gherkin table cell against value received from database.
+//
+// NULL matches nil value, variable without value is populated with received value,
+// JSON array or object is compared semantically.
+func (m *Manager) matchCell(cell string, received interface{}) error {
+	if b, ok := received.([]byte); ok {
+		received = string(b)
+	}
+
+	if m.Vars.IsVar(cell) {
+		val, found := m.Vars.Get(cell)
+		if !found {
+			m.Vars.Set(cell, received)
+
+			return nil
+		}
+
+		enc, err := m.TableMapper.Encode(val)
+		if err != nil {
+			return err
+		}
+
+		cell = enc
+	}
+
+	cell = strings.TrimSuffix(cell, "::string")
+
+	rcv, err := m.TableMapper.encodeCell(received)
+	if err != nil {
+		return err
+	}
+
+	if cell == rcv {
+		return nil
+	}
+
+	if len(cell) > 0 && (cell[0] == '{' || cell[0] == '[') && json.Valid([]byte(cell)) {
+		var exp, act interface{}
+
+		if json.Unmarshal([]byte(cell), &exp) == nil && json.Unmarshal([]byte(rcv), &act) == nil &&
+			reflect.DeepEqual(exp, act) {
+			return nil
+		}
+	}
+
+	if tm, ok := received.(time.Time); ok && cell != null {
+		if exp, err := ParseTime(cell); err == nil && exp.Equal(tm) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w %q, %q expected", errUnexpectedValue, rcv, cell)
+}
+
+// iStoreSQLQueryResultInDatabaseIntoVariables sets comma-separated variables with values of the first row of
+// query result in order of columns.
+func (m *Manager) iStoreSQLQueryResultInDatabaseIntoVariables(ctx context.Context, dbName, names, query string) (err error) {
+	ctx, err = m.sqlQueryInDatabaseIs(ctx, dbName, query)
+	if err != nil {
+		return err
+	}
+
+	cols, values, err := m.querySQL(ctx, dbName)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// Expose query result to simplify test debugging.
+		if err != nil {
+			err = m.exposeResult(err, cols, values)
+		}
+	}()
+
+	vars := strings.Split(names, ",")
+
+	if len(vars) > len(cols) {
+		return fmt.Errorf("%w: %d variables, %d columns", errInvalidNumberOfColumns, len(vars), len(cols))
+	}
+
+	if len(values) == 0 {
+		return fmt.Errorf("%w: at least 1 expected, 0 found", errInvalidNumberOfRows)
+	}
+
+	// Variables are assigned by column index, so that columns with duplicate names are not mixed up.
+	for i, name := range vars {
+		name = strings.TrimSpace(name)
+
+		if !m.Vars.IsVar(name) {
+			return fmt.Errorf("%w: %s", errNotVariable, name)
+		}
+
+		val := values[0][i]
+		if b, ok := val.([]byte); ok {
+			val = string(b)
+		}
+
+		m.Vars.Set(name, val)
+	}
+
+	return nil
+}
+
+// iStoreTheResultOfSQLQueryInDatabaseIntoVariables sets variables from gherkin table with column names in header
+// and variable names in rows, rows of table correspond to rows of query result.
+func (m *Manager) iStoreTheResultOfSQLQueryInDatabaseIntoVariables(ctx context.Context, dbName string, data [][]string) error {
+	cols, values, err := m.querySQL(ctx, dbName)
+	if err != nil {
+		return err
+	}
+
+	return m.storeVars(cols, values, data)
+}
+
+func (m *Manager) storeVars(cols []string, values [][]interface{}, data [][]string) (err error) {
+	defer func() {
+		// Expose query result to simplify test debugging.
+		if err != nil {
+			err = m.exposeResult(err, cols, values)
+		}
+	}()
+
+	if len(data) < 2 {
+		return errRowRequired
+	}
+
+	if len(data)-1 > len(values) {
+		return fmt.Errorf("%w: at least %d expected, %d found", errInvalidNumberOfRows, len(data)-1, len(values))
+	}
+
+	for j, name := range data[0] {
+		colIdx := -1
+
+		for i, col := range cols {
+			if col == name {
+				colIdx = i
+			}
+		}
+
+		if colIdx == -1 {
+			return fmt.Errorf("%w %s in SQL query result", errUnknownColumn, name)
+		}
+
+		for i, row := range data[1:] {
+			if !m.Vars.IsVar(row[j]) {
+				return fmt.Errorf("%w: %s", errNotVariable, row[j])
+			}
+
+			val := values[i][colIdx]
+			if b, ok := val.([]byte); ok {
+				val = string(b)
+			}
+
+			m.Vars.Set(row[j], val)
+		}
+	}
+
+	return nil
+}
+
+// exposeResult adds rendered query result to error.
+func (m *Manager) exposeResult(err error, cols []string, values [][]interface{}) error {
+	var (
+		width = make(map[string]int, len(cols))
+		res   = make(map[string][]string, len(cols))
+	)
+
+	for _, col := range cols {
+		width[col] = len(col)
+	}
+
+	for _, row := range values {
+		for i, col := range cols {
+			v, encErr := m.TableMapper.encodeCell(row[i])
+			if encErr != nil {
+				return fmt.Errorf("%w, failed to encode query result: %v", err, encErr)
+			}
+
+			if len(v) > width[col] {
+				width[col] = len(v)
+			}
+
+			res[col] = append(res[col], v)
+		}
+	}
+
+	return fmt.Errorf("%w, query result:\n%v", err, renderRows(cols, res, width, len(values)))
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/swaggest/form/v5"
@@ -48,20 +50,33 @@ func (m *TableMapper) Encode(v interface{}) (string, error) {
 	return vv[""][0], nil
 }
 
+// encodeCell converts value scanned from database to a table cell.
+func (m *TableMapper) encodeCell(v interface{}) (string, error) {
+	if v == nil {
+		return null, nil
+	}
+
+	if b, ok := v.([]byte); ok {
+		return string(b), nil
+	}
+
+	return m.Encode(v)
+}
+
 // SliceFromTable creates a slice from gherkin table, item type is used as slice element type.
+//
+// Row or map[string]interface{} item is mapped to a structure, see Row.Struct.
 func (m *TableMapper) SliceFromTable(data [][]string, item interface{}) (interface{}, error) {
-	itemType := reflect.TypeOf(item)
-	if itemType == nil {
-		return nil, errNilItemStruct
-	}
+	item = rowItem(item)
 
-	if itemType.Kind() == reflect.Ptr {
-		itemType = itemType.Elem()
+	itemType, err := itemType(item)
+	if err != nil {
+		return nil, err
 	}
 
 	result := reflect.MakeSlice(reflect.SliceOf(itemType), len(data)-1, len(data)-1)
 
-	err := m.IterateTable(IterateConfig{
+	err = m.IterateTable(IterateConfig{
 		Data: data, Item: item,
 		ReceiveRow: func(index int, row interface{}, colNames []string, rawValues []string) error {
 			result.Index(index).Set(reflect.Indirect(reflect.ValueOf(row)))
@@ -83,6 +98,33 @@ type IterateConfig struct {
 	Item       interface{}
 	Replaces   map[string]string
 	ReceiveRow func(index int, row interface{}, colNames []string, rawValues []string) error
+
+	// CollectErrors enables iteration of all rows even if receiver fails,
+	// receiver errors are returned as RowErrors.
+	CollectErrors bool
+}
+
+// RowError is an error of a particular row.
+type RowError struct {
+	Index int
+	Err   error
+}
+
+// RowErrors is a list of failed rows.
+type RowErrors struct {
+	Total  int
+	Errors []RowError
+}
+
+// Error implements error.
+func (e RowErrors) Error() string {
+	res := fmt.Sprintf("%d of %d rows failed:", len(e.Errors), e.Total)
+
+	for _, re := range e.Errors {
+		res += fmt.Sprintf("\nrow %d: %v", re.Index, re.Err)
+	}
+
+	return res
 }
 
 var (
@@ -90,8 +132,66 @@ var (
 	errRowRequired   = errors.New("header and at least one row required in table")
 )
 
+// Row defines row structure with column types, it can be used instead of Go struct
+// as an item in Instance.Tables or IterateConfig.Item.
+//
+// Values are type hints of columns, e.g. `dbdog.Row{"id": 0, "deleted_at": new(time.Time), "meta": Meta{}}`,
+// nil hint is a nullable string. Custom decoders of TableMapper.Decoder are applied to hinted types.
+type Row map[string]interface{}
+
+// Struct returns a pointer to a new structure with fields of row columns, columns are ordered by name.
+func (r Row) Struct() interface{} {
+	names := make([]string, 0, len(r))
+
+	for name := range r {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	types := make([]reflect.Type, 0, len(names))
+
+	for _, name := range names {
+		t := reflect.TypeOf(r[name])
+		if t == nil {
+			t = reflect.TypeOf(new(string))
+		}
+
+		types = append(types, t)
+	}
+
+	return rowStruct(names, types)
+}
+
+// rowStruct creates a pointer to a new structure with fields tagged with column names.
+func rowStruct(names []string, types []reflect.Type) interface{} {
+	fields := make([]reflect.StructField, 0, len(names))
+
+	for i, name := range names {
+		fields = append(fields, reflect.StructField{
+			Name: "F" + strconv.Itoa(i),
+			Type: types[i],
+			Tag:  reflect.StructTag(`db:"` + name + `"`),
+		})
+	}
+
+	return reflect.New(reflect.StructOf(fields)).Interface()
+}
+
+// rowItem replaces Row or map item with a structure.
+func rowItem(v interface{}) interface{} {
+	switch r := v.(type) {
+	case Row:
+		return r.Struct()
+	case map[string]interface{}:
+		return Row(r).Struct()
+	default:
+		return v
+	}
+}
+
 func itemType(v interface{}) (reflect.Type, error) {
-	itemType := reflect.TypeOf(v)
+	itemType := reflect.TypeOf(rowItem(v))
 	if itemType == nil {
 		return nil, errNilItemStruct
 	}
@@ -104,7 +204,8 @@ func itemType(v interface{}) (reflect.Type, error) {
 }
 
 // IterateTable walks gherkin table calling row receiver with mapped row.
-// If receiver returns error iteration stops and error is propagated.
+// If receiver returns error iteration stops and error is propagated,
+// unless IterateConfig.CollectErrors is enabled.
 func (m *TableMapper) IterateTable(c IterateConfig) error {
 	if m.Decoder == nil {
 		m.Decoder = form.NewDecoder()
@@ -122,6 +223,7 @@ func (m *TableMapper) IterateTable(c IterateConfig) error {
 	}
 
 	values := make(map[string][]string, len(colNames))
+	rowErrors := RowErrors{Total: len(c.Data) - 1}
 
 	for rowIndex, row := range c.Data[1:] {
 		itemBuf := reflect.New(itemType)
@@ -156,9 +258,17 @@ func (m *TableMapper) IterateTable(c IterateConfig) error {
 
 		err = c.ReceiveRow(rowIndex, itemBuf.Interface(), colNames, raw)
 		if err != nil {
-			return err
+			if !c.CollectErrors {
+				return err
+			}
+
+			rowErrors.Errors = append(rowErrors.Errors, RowError{Index: rowIndex, Err: err})
 		}
 	}
 
+	if len(rowErrors.Errors) > 0 {
+		return rowErrors
+	}
+
 	return nil
 }
@@ -1,6 +1,9 @@
 package dbdog_test
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -63,3 +66,71 @@ func TestTableMapper_Encode(t *testing.T) {
 		assert.Equal(t, tc.s, s)
 	}
 }
+
+func TestTableMapper_IterateTable_collectErrors(t *testing.T) {
+	type item struct {
+		A int `db:"a"`
+	}
+
+	m := dbdog.NewTableMapper()
+	received := 0
+
+	err := m.IterateTable(dbdog.IterateConfig{
+		Data: [][]string{
+			{"a"},
+			{"1"},
+			{"2"},
+			{"3"},
+		},
+		Item:          new(item),
+		CollectErrors: true,
+		ReceiveRow: func(index int, row interface{}, colNames []string, rawValues []string) error {
+			received++
+
+			if row.(*item).A != 2 {
+				return errors.New("failed")
+			}
+
+			return nil
+		},
+	})
+
+	assert.Equal(t, 3, received)
+	assert.EqualError(t, err, "2 of 3 rows failed:\nrow 0: failed\nrow 2: failed")
+
+	var rowErrors dbdog.RowErrors
+
+	assert.True(t, errors.As(err, &rowErrors))
+	assert.Len(t, rowErrors.Errors, 2)
+}
+
+func TestTableMapper_SliceFromTable_row(t *testing.T) {
+	type meta struct {
+		Tags []string
+	}
+
+	m := dbdog.NewTableMapper()
+	m.Decoder.RegisterFunc(func(s string) (interface{}, error) {
+		return meta{Tags: strings.Split(s, ",")}, nil
+	}, meta{})
+
+	data := [][]string{
+		{"id", "name", "meta", "deleted_at"},
+		{"1", "foo", "a,b", "NULL"},
+		{"2", "NULL", "c", "2021-01-01T00:00:00Z"},
+	}
+
+	res, err := m.SliceFromTable(data, dbdog.Row{"id": 0, "name": nil, "meta": meta{}, "deleted_at": new(time.Time)})
+	assert.NoError(t, err)
+
+	rows := reflect.ValueOf(res)
+	assert.Equal(t, 2, rows.Len())
+
+	assert.Equal(t, 1, rows.Index(0).FieldByName("F1").Interface())
+	assert.Equal(t, meta{Tags: []string{"a", "b"}}, rows.Index(0).FieldByName("F2").Interface())
+	assert.Equal(t, "foo", *rows.Index(0).FieldByName("F3").Interface().(*string))
+	assert.Nil(t, rows.Index(0).FieldByName("F0").Interface())
+	assert.Nil(t, rows.Index(1).FieldByName("F3").Interface())
+	assert.Equal(t, "2021-01-01T00:00:00Z",
+		rows.Index(1).FieldByName("F0").Interface().(*time.Time).Format(time.RFC3339))
+}